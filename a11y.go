@@ -0,0 +1,132 @@
+package htmlcheck
+
+import (
+	"strconv"
+	"strings"
+
+	html "github.com/BlackEspresso/htmlcheck/htmlp"
+)
+
+var thScopes = setOf("col", "row", "colgroup", "rowgroup")
+
+// checkA11y runs the accessibility rules enabled by CheckA11y on a start tag.
+func (v *Validator) checkA11y(s *validation, token html.Token, pos Span) {
+	if value, ok := attrValue(token, "tabindex"); ok {
+		tabindex, err := strconv.Atoi(strings.TrimSpace(value))
+		if err == nil && tabindex > 0 {
+			cError := v.report(s, token.Data, "tabindex", value, pos,
+				InvPositiveTabindex)
+			if cError != nil {
+				cError.Note = "use tabindex=\"0\" to make an element " +
+					"focusable or \"-1\" to focus it from script only"
+			}
+		}
+	}
+
+	if kind := landmarkKind(token); kind != "" {
+		if s.landmarks == nil {
+			s.landmarks = map[string][]landmark{}
+		}
+		s.landmarks[kind] = append(s.landmarks[kind], landmark{
+			tagName: token.Data,
+			pos:     pos,
+			textPos: s.textPos(pos.Start),
+			labeled: hasAttr(token, "aria-label") ||
+				hasAttr(token, "aria-labelledby"),
+		})
+	}
+
+	switch token.Data {
+	case "th":
+		scope, ok := attrValue(token, "scope")
+		if !ok {
+			cError := v.report(s, token.Data, "scope", "", pos,
+				InvMissingScope)
+			if cError != nil {
+				cError.Note = "set scope=\"col\" or scope=\"row\" so " +
+					"screen readers know which cells it labels"
+			}
+		} else if !thScopes[strings.ToLower(strings.TrimSpace(scope))] {
+			v.report(s, token.Data, "scope", scope, pos, InvBadScope)
+		}
+	case "main":
+		if hasAttr(token, "hidden") {
+			return
+		}
+		s.mainCount++
+		if s.mainCount > 1 {
+			cError := v.report(s, token.Data, "", "", pos, InvMultipleMain)
+			if cError != nil {
+				cError.Note = "a document should have at most one visible " +
+					"<main>, hide the others with the hidden attribute"
+			}
+		}
+	}
+}
+
+type landmark struct {
+	tagName string
+	pos     Span
+	textPos *TextPos
+	labeled bool
+}
+
+// landmarkKind returns the landmark role of a start tag that needs a name
+// when it is repeated, or "" for other tags.
+func landmarkKind(token html.Token) string {
+	if role, ok := attrValue(token, "role"); ok {
+		switch strings.TrimSpace(role) {
+		case "navigation":
+			return "nav"
+		case "complementary":
+			return "aside"
+		}
+	}
+	switch token.Data {
+	case "nav", "aside":
+		return token.Data
+	}
+	return ""
+}
+
+// checkLandmarks reports the unlabeled landmarks of every landmark role
+// used more than once, as they can't be told apart.
+func (v *Validator) checkLandmarks(s *validation) {
+	for _, kind := range []string{"nav", "aside"} {
+		landmarks := s.landmarks[kind]
+		if len(landmarks) < 2 {
+			continue
+		}
+		for _, l := range landmarks {
+			if l.labeled {
+				continue
+			}
+			cError := v.reportAt(s, l.tagName, "", "", l.pos, l.textPos,
+				InvAmbiguousLandmark)
+			if cError != nil {
+				cError.Note = "there is more than one '" + kind +
+					"' landmark, name each with aria-label or aria-labelledby"
+			}
+			if v.stopped(s) {
+				return
+			}
+		}
+	}
+}
+
+// checkAriaNames reports aria-* attributes that are not ARIA states or
+// properties, suggesting the closest known one.
+func (v *Validator) checkAriaNames(s *validation, token html.Token, pos Span) {
+	for _, attr := range token.Attr {
+		if !strings.HasPrefix(attr.Key, "aria-") || AriaAttributes[attr.Key] {
+			continue
+		}
+		cError := v.report(s, token.Data, attr.Key, attr.Val, pos,
+			InvUnknownAria)
+		if cError != nil {
+			if name := closest(attr.Key, AriaAttributes); name != "" {
+				cError.Note = "did you mean '" + name + "'?"
+			}
+		}
+	}
+}
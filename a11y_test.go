@@ -0,0 +1,86 @@
+package htmlcheck
+
+import (
+	"testing"
+)
+
+func newA11yValidator(tags ...ValidTag) *Validator {
+	val := newValidator(append(tags, ValidTag{Name: "div"},
+		ValidTag{Name: "main", Attrs: []string{"hidden"}})...)
+	val.CheckA11y = true
+	return val
+}
+
+func Test_MultipleMain(t *testing.T) {
+	val := newA11yValidator()
+
+	errors := val.ValidateHtmlString("<div></div>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<main></main>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<main></main><main hidden></main>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<main></main><div><main></main></div>")
+	hasReason(t, errors, InvMultipleMain)
+}
+
+func Test_PositiveTabindex(t *testing.T) {
+	val := newA11yValidator()
+	val.AddValidTag(ValidTag{Name: "", Attrs: []string{"tabindex"}})
+
+	errors := val.ValidateHtmlString("<div tabindex='0'></div><div tabindex='-1'></div>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<div tabindex='3'></div>")
+	hasReason(t, errors, InvPositiveTabindex)
+	if errors[0].Severity != SeverityWarning || errors[0].Note == "" {
+		t.Fatal(errors[0])
+	}
+}
+
+func Test_AriaNames(t *testing.T) {
+	val := newValidator(ValidTag{Name: "div", AttrStartsWith: "aria-"})
+	val.CheckAriaNames = true
+
+	errors := val.ValidateHtmlString(`<div aria-labelledby="t" aria-hidden="true"></div>`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<div aria-labeledby="t"></div>`)
+	hasReason(t, errors, InvUnknownAria)
+	if errors[0].Note != "did you mean 'aria-labelledby'?" {
+		t.Fatal("expected a suggestion", errors[0].Note)
+	}
+}
+
+func Test_AmbiguousLandmark(t *testing.T) {
+	val := newA11yValidator(ValidTag{Name: "nav", Attrs: []string{"aria-label"}})
+
+	errors := val.ValidateHtmlString("<nav></nav>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<nav aria-label="Main"></nav><nav aria-label="Footer"></nav>`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<nav aria-label="Main"></nav><nav></nav>`)
+	hasReason(t, errors, InvAmbiguousLandmark)
+	if len(errors) != 1 || errors[0].Pos.Start != 30 {
+		t.Fatal("expected the unlabeled nav", errors)
+	}
+}
+
+func Test_ThScope(t *testing.T) {
+	val := newA11yValidator(ValidTag{Name: "table"}, ValidTag{Name: "tr"},
+		ValidTag{Name: "th", Attrs: []string{"scope"}})
+
+	errors := val.ValidateHtmlString(`<table><tr><th scope="col">Name</th><th scope="Row">Age</th></tr></table>`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<table><tr><th>Name</th></tr></table>`)
+	hasReason(t, errors, InvMissingScope)
+
+	errors = val.ValidateHtmlString(`<table><tr><th scope="column">Name</th></tr></table>`)
+	hasReason(t, errors, InvBadScope)
+}
@@ -0,0 +1,177 @@
+package htmlcheck
+
+import (
+	"regexp"
+)
+
+// Clone returns a copy of v that can be changed without affecting v. Tags,
+// groups, options and callbacks are copied; timings start at zero.
+func (v *Validator) Clone() *Validator {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	c := &Validator{
+		errorCallback:          v.errorCallback,
+		contextCallback:        v.contextCallback,
+		StopAfterFirstError:    v.StopAfterFirstError,
+		Mode:                   v.Mode,
+		MaxErrors:              v.MaxErrors,
+		TreatWarningsAsErrors:  v.TreatWarningsAsErrors,
+		CheckAttrEscaping:      v.CheckAttrEscaping,
+		CheckMalformedAttrs:    v.CheckMalformedAttrs,
+		CheckButtonType:        v.CheckButtonType,
+		RequireScriptNonce:     v.RequireScriptNonce,
+		BlockEventHandlers:     v.BlockEventHandlers,
+		CheckURLs:              v.CheckURLs,
+		URLAttrs:               copyStrings(v.URLAttrs),
+		AllowedSchemes:         copyStrings(v.AllowedSchemes),
+		AllowedComment:         v.AllowedComment,
+		DisallowedComment:      v.DisallowedComment,
+		CheckComments:          v.CheckComments,
+		CheckCharsetFirst:      v.CheckCharsetFirst,
+		CheckForms:             v.CheckForms,
+		CheckUniqueIds:         v.CheckUniqueIds,
+		UniqueAttr:             v.UniqueAttr,
+		Profile:                v.Profile,
+		CheckRel:               v.CheckRel,
+		SkipInside:             copyStrings(v.SkipInside),
+		CheckViewport:          v.CheckViewport,
+		CheckHttpEquiv:         v.CheckHttpEquiv,
+		CheckTitle:             v.CheckTitle,
+		RequireTitle:           v.RequireTitle,
+		CheckDatetime:          v.CheckDatetime,
+		WarnOnAttrAlias:        v.WarnOnAttrAlias,
+		SuggestAttributes:      v.SuggestAttributes,
+		CheckVoidEndTags:       v.CheckVoidEndTags,
+		CheckSelfClosingSyntax: v.CheckSelfClosingSyntax,
+		RequireDoubleQuotes:    v.RequireDoubleQuotes,
+		RejectEmptyValues:      v.RejectEmptyValues,
+		CheckAutocomplete:      v.CheckAutocomplete,
+		CheckA11y:              v.CheckA11y,
+		CheckAriaNames:         v.CheckAriaNames,
+		CheckSourceContext:     v.CheckSourceContext,
+		CheckTracks:            v.CheckTracks,
+		CheckLoading:           v.CheckLoading,
+		LazyLoadMinArea:        v.LazyLoadMinArea,
+		MaxInlineDataSize:      v.MaxInlineDataSize,
+		CheckDoctype:           v.CheckDoctype,
+		DoctypeForm:            v.DoctypeForm,
+		RequireDoctype:         v.RequireDoctype,
+		AllowedDoctypes:        copyStrings(v.AllowedDoctypes),
+		CheckContentCategories: v.CheckContentCategories,
+		UnknownTagsAreVoid:     v.UnknownTagsAreVoid,
+		AllowUnknownTags:       v.AllowUnknownTags,
+		HardMaxDepth:           v.HardMaxDepth,
+		MaxDepth:               v.MaxDepth,
+		MaxAttrsPerTag:         v.MaxAttrsPerTag,
+		MeaningfulContent:      v.MeaningfulContent,
+		hasValueAttrs:          v.hasValueAttrs,
+		hasAutoClose:           v.hasAutoClose,
+	}
+
+	if v.Severities != nil {
+		c.Severities = map[ErrorReason]Severity{}
+		for reason, severity := range v.Severities {
+			c.Severities[reason] = severity
+		}
+	}
+	c.AttrAliases = copyStringMap(v.AttrAliases)
+	if v.MaxChainDepth != nil {
+		c.MaxChainDepth = map[string]int{}
+		for name, depth := range v.MaxChainDepth {
+			c.MaxChainDepth[name] = depth
+		}
+	}
+
+	if v.validTagMap != nil {
+		c.validTagMap = map[string]map[string]bool{}
+		for name, attrs := range v.validTagMap {
+			c.validTagMap[name] = map[string]bool{}
+			for attr := range attrs {
+				c.validTagMap[name][attr] = true
+			}
+		}
+	}
+	if v.validSelfClosingTags != nil {
+		c.validSelfClosingTags = map[string]bool{}
+		for name := range v.validSelfClosingTags {
+			c.validSelfClosingTags[name] = true
+		}
+	}
+	if v.validTags != nil {
+		c.validTags = map[string]*ValidTag{}
+		for name, tag := range v.validTags {
+			c.validTags[name] = tag
+		}
+	}
+	if v.validGroups != nil {
+		c.validGroups = map[string]*TagGroup{}
+		for name, group := range v.validGroups {
+			c.validGroups[name] = group
+		}
+	}
+	if v.attrValuePatterns != nil {
+		c.attrValuePatterns = map[string]map[string]*regexp.Regexp{}
+		for name, patterns := range v.attrValuePatterns {
+			c.attrValuePatterns[name] = patterns
+		}
+	}
+	if v.attrNamePatterns != nil {
+		c.attrNamePatterns = map[string]*regexp.Regexp{}
+		for name, pattern := range v.attrNamePatterns {
+			c.attrNamePatterns[name] = pattern
+		}
+	}
+	if v.globalAttrs != nil {
+		c.globalAttrs = map[string]bool{}
+		for attr := range v.globalAttrs {
+			c.globalAttrs[attr] = true
+		}
+	}
+	c.globalAttrPatterns = append([]*regexp.Regexp(nil),
+		v.globalAttrPatterns...)
+	if v.dataAttrs != nil {
+		c.dataAttrs = map[string]*regexp.Regexp{}
+		for name, re := range v.dataAttrs {
+			c.dataAttrs[name] = re
+		}
+	}
+	if v.namespaces != nil {
+		c.namespaces = map[string]bool{}
+		for root := range v.namespaces {
+			c.namespaces[root] = true
+		}
+	}
+	if v.disabledReasons != nil {
+		c.disabledReasons = map[ErrorReason]bool{}
+		for reason := range v.disabledReasons {
+			c.disabledReasons[reason] = true
+		}
+	}
+	if v.closeRules != nil {
+		c.closeRules = map[string][]CloseRule{}
+		for name, rules := range v.closeRules {
+			c.closeRules[name] = append([]CloseRule(nil), rules...)
+		}
+	}
+	return c
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, val := range m {
+		c[k] = val
+	}
+	return c
+}
+
+// copyStrings copies s, keeping nil and empty slices apart.
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string{}, s...)
+}
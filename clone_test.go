@@ -0,0 +1,62 @@
+package htmlcheck
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func Test_Clone(t *testing.T) {
+	base := newValidator(ValidTag{Name: "p"}, ValidTag{Name: "iframe"},
+		ValidTag{Name: "br", IsSelfClosing: true})
+	base.CheckForms = true
+
+	clone := base.Clone()
+	clone.RemoveTag("iframe")
+	clone.AddValidTag(ValidTag{Name: "b"})
+	clone.CheckForms = false
+
+	if !base.IsValidTag("iframe") || base.IsValidTag("b") || !base.CheckForms {
+		t.Fatal("changing the clone changed the original")
+	}
+	if clone.IsValidTag("iframe") || !clone.IsValidTag("b") ||
+		!clone.IsValidSelfClosingTag("br") {
+		t.Fatal("clone has the wrong tags")
+	}
+}
+
+func Test_CloneCopiesOptions(t *testing.T) {
+	val := &Validator{}
+	rv := reflect.ValueOf(val).Elem()
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+		switch field.Kind() {
+		case reflect.Bool:
+			field.SetBool(true)
+		case reflect.Int:
+			field.SetInt(1)
+		case reflect.String:
+			field.SetString("x")
+		case reflect.Map:
+			field.Set(reflect.MakeMap(field.Type()))
+		case reflect.Slice:
+			field.Set(reflect.MakeSlice(field.Type(), 1, 1))
+		case reflect.Func:
+			field.Set(reflect.MakeFunc(field.Type(), nil))
+		case reflect.Ptr:
+			field.Set(reflect.ValueOf(regexp.MustCompile("x")))
+		default:
+			t.Fatal("unhandled field", rv.Type().Field(i).Name)
+		}
+	}
+
+	clone := reflect.ValueOf(val.Clone()).Elem()
+	for i := 0; i < clone.NumField(); i++ {
+		if rv.Field(i).CanSet() && clone.Field(i).IsZero() {
+			t.Fatal("Clone doesn't copy", rv.Type().Field(i).Name)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package htmlcheck
+
+import (
+	"strings"
+)
+
+// IsMeaningfulContent reports whether text counts as content for the
+// emptiness and required-child rules. It uses MeaningfulContent if set,
+// otherwise any text that is not only Unicode white space (which
+// includes the non-breaking space) is meaningful.
+func (v *Validator) IsMeaningfulContent(text string) bool {
+	if v.MeaningfulContent != nil {
+		return v.MeaningfulContent(text)
+	}
+	return strings.TrimSpace(text) != ""
+}
@@ -0,0 +1,121 @@
+package htmlcheck
+
+import (
+	"strings"
+
+	html "github.com/BlackEspresso/htmlcheck/htmlp"
+)
+
+func setOf(names ...string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+var phrasingElements = []string{"a", "abbr", "area", "audio", "b", "bdi",
+	"bdo", "br", "button", "canvas", "cite", "code", "data", "datalist",
+	"del", "dfn", "em", "embed", "i", "iframe", "img", "input", "ins", "kbd",
+	"label", "link", "map", "mark", "math", "meta", "meter", "noscript",
+	"object", "output", "picture", "progress", "q", "ruby", "s", "samp",
+	"script", "select", "slot", "small", "span", "strong", "sub", "sup",
+	"svg", "template", "textarea", "time", "u", "var", "video", "wbr"}
+
+// ContentCategories lists the members of each content category for the
+// standard elements. Elements whose membership depends on attributes are
+// listed in the category they usually belong to.
+var ContentCategories = map[string]map[string]bool{
+	"metadata": setOf("base", "link", "meta", "noscript", "script",
+		"style", "template", "title"),
+	"flow": setOf(append([]string{"address", "article", "aside",
+		"blockquote", "details", "dialog", "div", "dl", "fieldset",
+		"figure", "footer", "form", "h1", "h2", "h3", "h4", "h5", "h6",
+		"header", "hgroup", "hr", "main", "menu", "nav", "ol", "p", "pre",
+		"search", "section", "style", "table", "ul"}, phrasingElements...)...),
+	"sectioning": setOf("article", "aside", "nav", "section"),
+	"heading":    setOf("h1", "h2", "h3", "h4", "h5", "h6", "hgroup"),
+	"phrasing":   setOf(phrasingElements...),
+	"embedded": setOf("audio", "canvas", "embed", "iframe", "img", "math",
+		"object", "picture", "svg", "video"),
+	"interactive": setOf("a", "button", "details", "embed", "iframe",
+		"input", "label", "select", "textarea"),
+}
+
+// phrasingParents are the elements whose children must be phrasing content.
+var phrasingParents = setOf("abbr", "b", "bdi", "bdo", "button", "cite",
+	"code", "data", "dfn", "em", "h1", "h2", "h3", "h4", "h5", "h6", "i",
+	"kbd", "label", "mark", "output", "p", "pre", "q", "s", "samp", "small",
+	"span", "strong", "sub", "sup", "time", "u", "var")
+
+// transparentElements take the content model of their own parent.
+var transparentElements = setOf("a", "audio", "canvas", "del", "ins", "map",
+	"noscript", "object", "slot", "video")
+
+// isInCategory reports whether the element is in the content category.
+// Custom elements are flow and phrasing content.
+func isInCategory(tagName string, category string) bool {
+	if strings.Contains(tagName, "-") {
+		return category == "flow" || category == "phrasing"
+	}
+	return ContentCategories[category][tagName]
+}
+
+func isKnownElement(tagName string) bool {
+	if strings.Contains(tagName, "-") {
+		return true
+	}
+	for _, members := range ContentCategories {
+		if members[tagName] {
+			return true
+		}
+	}
+	return false
+}
+
+// checkContentCategories verifies that a start tag fits the content model
+// of the elements it is nested in. pushed tells whether the tag is on the
+// parents stack.
+func (v *Validator) checkContentCategories(s *validation, token html.Token,
+	tagName string, pos Span, pushed bool) {
+	if !isKnownElement(tagName) {
+		return
+	}
+
+	interactive := isInCategory(tagName, "interactive")
+	if value, _ := attrValue(token, "type"); tagName == "input" &&
+		strings.EqualFold(strings.TrimSpace(value), "hidden") {
+		interactive = false
+	}
+
+	checkPhrasing := true
+	i := len(s.parents) - 1
+	if pushed {
+		i--
+	}
+	for ; i >= 0; i-- {
+		parent := s.parents[i].name
+		if v.isValidSelfClosingTag(parent) {
+			continue
+		}
+		if interactive && (parent == "a" || parent == "button") {
+			cError := v.report(s, tagName, "", "", pos, InvContentModel)
+			if cError != nil {
+				cError.Note = "interactive content is not allowed inside '" +
+					parent + "'"
+			}
+			return
+		}
+		if !checkPhrasing || transparentElements[parent] {
+			continue
+		}
+		checkPhrasing = false
+		if phrasingParents[parent] && !isInCategory(tagName, "phrasing") {
+			cError := v.report(s, tagName, "", "", pos, InvContentModel)
+			if cError != nil {
+				cError.Note = "'" + parent + "' only allows phrasing content"
+			}
+			return
+		}
+	}
+}
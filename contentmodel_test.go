@@ -0,0 +1,38 @@
+package htmlcheck
+
+import (
+	"testing"
+)
+
+func Test_ContentCategories(t *testing.T) {
+	val := newValidator(ValidTag{Name: "div"}, ValidTag{Name: "span"},
+		ValidTag{Name: "p"}, ValidTag{Name: "a", Attrs: []string{"href"}},
+		ValidTag{Name: "button"}, ValidTag{Name: "br", IsSelfClosing: true},
+		ValidTag{Name: "input", Attrs: []string{"type"}, IsSelfClosing: true})
+	val.CheckContentCategories = true
+
+	errors := val.ValidateHtmlString(`<div><span>a<br>b</span><p><a href="#">x</a></p>` +
+		`<a href="#"><div>block link</div></a><button><input type="hidden"></button></div>`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<span><div></div></span>")
+	hasReason(t, errors, InvContentModel)
+
+	errors = val.ValidateHtmlString(`<p><a href="#"><div></div></a></p>`)
+	hasReason(t, errors, InvContentModel)
+
+	errors = val.ValidateHtmlString(`<a href="#"><button></button></a>`)
+	hasReason(t, errors, InvContentModel)
+}
+
+func Test_ContentCategoriesNamespace(t *testing.T) {
+	val := newValidator(ValidTag{Name: "button"}, ValidTag{Name: "span"},
+		ValidTag{Name: "svg"})
+	val.CheckContentCategories = true
+	if err := val.AddNamespace("svg", []*ValidTag{{Name: "a"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	errors := val.ValidateHtmlString(`<button><svg><a/></svg></button><span><svg/></span>`)
+	checkErrors(t, errors)
+}
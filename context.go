@@ -0,0 +1,49 @@
+package htmlcheck
+
+// Context gives an error callback access to the state of the validation
+// that found the error. It is only valid until the callback returns.
+type Context struct {
+	s       *validation
+	pos     Span
+	textPos *TextPos
+}
+
+// ContextCallback is an ErrorCallback that also receives a Context.
+type ContextCallback func(ctx *Context, tagName string, attributeName string,
+	value string, reason ErrorReason) *ValidationError
+
+// RegisterContextCallback sets f as the error callback, replacing a callback
+// set with RegisterCallback.
+func (v *Validator) RegisterContextCallback(f ContextCallback) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.errorCallback = nil
+	v.contextCallback = f
+}
+
+// Ancestors returns the names of the open elements, outermost first, at the
+// point the error was found. It returns nil once the callback has returned.
+func (c *Context) Ancestors() []string {
+	if c.s == nil {
+		return nil
+	}
+	names := make([]string, len(c.s.parents))
+	for i, parent := range c.s.parents {
+		names[i] = parent.name
+	}
+	return names
+}
+
+// Pos returns the position of the error.
+func (c *Context) Pos() Span {
+	return c.pos
+}
+
+// TextPos returns the line and column of the error. It returns nil once the
+// callback has returned.
+func (c *Context) TextPos() *TextPos {
+	if c.s == nil {
+		return nil
+	}
+	return c.textPos
+}
@@ -0,0 +1,121 @@
+package htmlcheck
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	reMonth        = regexp.MustCompile(`^(\d{4,})-(\d{2})$`)
+	reDate         = regexp.MustCompile(`^(\d{4,})-(\d{2})-(\d{2})$`)
+	reYearlessDate = regexp.MustCompile(`^(?:--)?(\d{2})-(\d{2})$`)
+	reTime         = regexp.MustCompile(`^(\d{2}):(\d{2})(?::(\d{2})(?:\.\d{1,3})?)?$`)
+	reTimezone     = regexp.MustCompile(`^(?:Z|[+-](\d{2}):?(\d{2}))$`)
+	reWeek         = regexp.MustCompile(`^(\d{4,})-W(\d{2})$`)
+	reYear         = regexp.MustCompile(`^\d{4,}$`)
+	reISODuration  = regexp.MustCompile(`^P(?:\d+D)?(?:T(?:\d+H)?(?:\d+M)?(?:\d+(?:\.\d{1,3})?S)?)?$`)
+	reDurationPart = regexp.MustCompile(`^\s*(\d+(?:\.\d{1,3})?)\s*([WwDdHhMmSs])`)
+)
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+func daysIn(year, month int) int {
+	switch month {
+	case 2:
+		if year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+			return 29
+		}
+		return 28
+	case 4, 6, 9, 11:
+		return 30
+	}
+	return 31
+}
+
+func validMonth(year, month string) bool {
+	return atoi(year) > 0 && atoi(month) >= 1 && atoi(month) <= 12
+}
+
+func isDateString(s string) bool {
+	m := reDate.FindStringSubmatch(s)
+	if m == nil || !validMonth(m[1], m[2]) {
+		return false
+	}
+	day := atoi(m[3])
+	return day >= 1 && day <= daysIn(atoi(m[1]), atoi(m[2]))
+}
+
+func isTimeString(s string) bool {
+	m := reTime.FindStringSubmatch(s)
+	if m == nil {
+		return false
+	}
+	return atoi(m[1]) <= 23 && atoi(m[2]) <= 59 && (m[3] == "" || atoi(m[3]) <= 59)
+}
+
+func isTimezone(s string) bool {
+	m := reTimezone.FindStringSubmatch(s)
+	if m == nil {
+		return false
+	}
+	return s == "Z" || atoi(m[1]) <= 23 && atoi(m[2]) <= 59
+}
+
+// isDatetimeString accepts local and global date and time strings.
+func isDatetimeString(s string) bool {
+	sep := strings.IndexAny(s, "T ")
+	if sep < 0 || !isDateString(s[:sep]) {
+		return false
+	}
+	rest := s[sep+1:]
+	if isTimeString(rest) {
+		return true
+	}
+	tz := strings.IndexAny(rest, "Z+-")
+	return tz > 0 && isTimeString(rest[:tz]) && isTimezone(rest[tz:])
+}
+
+func isDurationString(s string) bool {
+	if len(s) > 1 && reISODuration.MatchString(s) && !strings.HasSuffix(s, "T") {
+		return true
+	}
+	seen := map[byte]bool{}
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		m := reDurationPart.FindStringSubmatch(s)
+		if m == nil {
+			return false
+		}
+		unit := strings.ToLower(m[2])[0]
+		if seen[unit] || unit != 's' && strings.Contains(m[1], ".") {
+			return false
+		}
+		seen[unit] = true
+		s = s[len(m[0]):]
+	}
+	return len(seen) > 0
+}
+
+// IsValidDatetime reports whether s is one of the date, time and duration
+// formats allowed in the HTML datetime attribute.
+func IsValidDatetime(s string) bool {
+	if m := reMonth.FindStringSubmatch(s); m != nil {
+		return validMonth(m[1], m[2])
+	}
+	if m := reYearlessDate.FindStringSubmatch(s); m != nil {
+		month, day := atoi(m[1]), atoi(m[2])
+		return month >= 1 && month <= 12 && day >= 1 && day <= daysIn(4, month)
+	}
+	if m := reWeek.FindStringSubmatch(s); m != nil {
+		week := atoi(m[2])
+		return atoi(m[1]) > 0 && week >= 1 && week <= 53
+	}
+	if reYear.MatchString(s) {
+		return atoi(s) > 0
+	}
+	return isDateString(s) || isTimeString(s) || isTimezone(s) ||
+		isDatetimeString(s) || isDurationString(s)
+}
@@ -0,0 +1,40 @@
+package htmlcheck
+
+import (
+	"testing"
+)
+
+func Test_IsValidDatetime(t *testing.T) {
+	valid := []string{"2011-11-18", "2011-11", "2012-02-29", "11-18", "--11-18",
+		"14:54", "14:54:39.929", "2011-11-18T14:54", "2011-11-18 14:54:39",
+		"2011-11-18T14:54:39.929Z", "2011-11-18T14:54+01:00",
+		"2011-11-18T14:54-0800", "2011-W47", "2011", "PT4H18M3S", "P1D",
+		"4h 18m 3s", "1w 2d", "Z", "+05:30"}
+	for _, s := range valid {
+		if !IsValidDatetime(s) {
+			t.Error("should be valid:", s)
+		}
+	}
+
+	invalid := []string{"", "yesterday", "2011-13-01", "2011-02-30",
+		"2013-02-29", "24:00", "14:60", "2011-11-18T", "2011-W54", "P",
+		"PT", "4h 4h", "1.5h", "18/11/2011"}
+	for _, s := range invalid {
+		if IsValidDatetime(s) {
+			t.Error("should be invalid:", s)
+		}
+	}
+}
+
+func Test_CheckDatetime(t *testing.T) {
+	val := newValidator(ValidTag{Name: "time", Attrs: []string{"datetime"}},
+		ValidTag{Name: "del", Attrs: []string{"datetime"}})
+	val.CheckDatetime = true
+
+	errors := val.ValidateHtmlString("<time datetime='2011-11-18'>Friday</time>" +
+		"<del datetime='2011-11-18T14:54:39Z'>x</del>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<time datetime='last friday'>Friday</time>")
+	hasReason(t, errors, InvBadDatetime)
+}
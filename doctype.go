@@ -0,0 +1,65 @@
+package htmlcheck
+
+import (
+	"strings"
+
+	html "github.com/BlackEspresso/htmlcheck/htmlp"
+)
+
+// checkDoctype rejects doctypes other than the HTML5 one or one of
+// AllowedDoctypes and, if DoctypeForm is set, HTML5 doctypes not written
+// exactly that way.
+func (v *Validator) checkDoctype(s *validation, raw string, data string,
+	pos Span) {
+	fields := strings.Fields(strings.ToLower(data))
+	for _, allowed := range v.AllowedDoctypes {
+		if strings.Join(fields, " ") ==
+			strings.Join(strings.Fields(strings.ToLower(allowed)), " ") {
+			return
+		}
+	}
+	legacyCompat := len(fields) == 3 && fields[1] == "system" &&
+		strings.Trim(fields[2], "\"'") == "about:legacy-compat"
+	if len(fields) == 0 || fields[0] != "html" ||
+		(len(fields) > 1 && !legacyCompat) {
+		cError := v.report(s, "", "", data, pos, InvLegacyDoctype)
+		if cError != nil {
+			cError.Note = "use <!DOCTYPE html>"
+			if strings.Contains(strings.ToLower(data), "xhtml") {
+				cError.Note += ", XHTML doctypes are not needed for HTML5"
+			} else if strings.Contains(data, "4.01") {
+				cError.Note += ", HTML 4.01 doctypes trigger legacy parsing"
+			}
+		}
+		return
+	}
+
+	if v.DoctypeForm != "" && raw != v.DoctypeForm {
+		cError := v.report(s, "", "", data, pos, InvDoctypeForm)
+		if cError != nil {
+			cError.Note = "write it as " + v.DoctypeForm
+		}
+	}
+}
+
+func (v *Validator) requiresDoctype() bool {
+	return v.RequireDoctype || v.Mode == ModeDocument
+}
+
+// checkFirstToken reports a document whose first token, ignoring white
+// space and comments, is not a doctype.
+func (v *Validator) checkFirstToken(s *validation, token html.Token,
+	pos Span) {
+	switch token.Type {
+	case html.CommentToken:
+		return
+	case html.TextToken:
+		if strings.TrimSpace(token.Data) == "" {
+			return
+		}
+	}
+	s.started = true
+	if token.Type != html.DoctypeToken {
+		v.report(s, "", "", "", pos, InvMissingDoctype)
+	}
+}
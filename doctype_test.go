@@ -0,0 +1,53 @@
+package htmlcheck
+
+import (
+	"testing"
+)
+
+func Test_Doctype(t *testing.T) {
+	val := newValidator(ValidTag{Name: "html"})
+	val.CheckDoctype = true
+
+	errors := val.ValidateHtmlString("<!DOCTYPE html><html></html>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<!doctype HTML><html></html>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Strict//EN" ` +
+		`"http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd"><html></html>`)
+	hasReason(t, errors, InvLegacyDoctype)
+
+	val.DoctypeForm = "<!DOCTYPE html>"
+	errors = val.ValidateHtmlString("<!DOCTYPE html><html></html>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<!doctype HTML><html></html>")
+	hasReason(t, errors, InvDoctypeForm)
+}
+
+func Test_RequireDoctype(t *testing.T) {
+	val := newValidator(ValidTag{Name: "html"})
+	val.RequireDoctype = true
+
+	errors := val.ValidateHtmlString("\n<!-- page -->\n<!DOCTYPE html><html></html>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<html></html>")
+	hasReason(t, errors, InvMissingDoctype)
+
+	errors = val.ValidateHtmlString("  ")
+	hasReason(t, errors, InvMissingDoctype)
+}
+
+func Test_AllowedDoctypes(t *testing.T) {
+	val := newValidator(ValidTag{Name: "html"})
+	val.CheckDoctype = true
+	val.AllowedDoctypes = []string{`html PUBLIC "-//W3C//DTD HTML 4.01//EN"`}
+
+	errors := val.ValidateHtmlString(`<!DOCTYPE html  public "-//W3C//DTD HTML 4.01//EN"><html></html>`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<!DOCTYPE html PUBLIC "-//W3C//DTD HTML 4.0//EN"><html></html>`)
+	hasReason(t, errors, InvLegacyDoctype)
+}
@@ -0,0 +1,114 @@
+package htmlcheck
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ValidateFile streams the file at path through the validator. The errors
+// carry line and column positions. The returned error is set if the file
+// can't be opened or read.
+func (v *Validator) ValidateFile(path string) ([]*ValidationError, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	errors := []*ValidationError{}
+	err = v.validate(bufio.NewReader(f), func(e *ValidationError) bool {
+		errors = append(errors, e)
+		return true
+	})
+	return errors, err
+}
+
+// ValidateFiles validates each file and returns the errors by path.
+func (v *Validator) ValidateFiles(paths []string) (
+	map[string][]*ValidationError, error) {
+	results := map[string][]*ValidationError{}
+	for _, path := range paths {
+		errors, err := v.ValidateFile(path)
+		if err != nil {
+			return results, err
+		}
+		results[path] = errors
+	}
+	return results, nil
+}
+
+// ValidateGlob validates all files matching pattern and returns the errors
+// by path. Besides the filepath.Match syntax the pattern may contain "**"
+// for any number of directories, as in "templates/**/*.html". A pattern
+// without matches returns an empty result.
+func (v *Validator) ValidateGlob(pattern string) (
+	map[string][]*ValidationError, error) {
+	paths, err := globFiles(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return v.ValidateFiles(paths)
+}
+
+func globFiles(pattern string) ([]string, error) {
+	pattern = filepath.ToSlash(pattern)
+	index := strings.Index(pattern, "**")
+	if index == -1 {
+		matches, err := filepath.Glob(filepath.FromSlash(pattern))
+		if err != nil {
+			return nil, err
+		}
+		return onlyFiles(matches), nil
+	}
+
+	root := strings.TrimSuffix(pattern[:index], "/")
+	if root == "" {
+		root = "."
+	}
+	rest := strings.TrimPrefix(pattern[index+2:], "/")
+	if rest == "" {
+		rest = "*"
+	}
+	if _, err := filepath.Match(rest, ""); err != nil {
+		return nil, err
+	}
+	depth := strings.Count(rest, "/") + 1
+
+	matches := []string{}
+	err := filepath.WalkDir(filepath.FromSlash(root),
+		func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) && path == filepath.FromSlash(root) {
+					return filepath.SkipDir
+				}
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			segments := strings.Split(filepath.ToSlash(path), "/")
+			if len(segments) < depth {
+				return nil
+			}
+			tail := strings.Join(segments[len(segments)-depth:], "/")
+			if ok, _ := filepath.Match(rest, tail); ok {
+				matches = append(matches, path)
+			}
+			return nil
+		})
+	return matches, err
+}
+
+func onlyFiles(paths []string) []string {
+	files := []string{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err == nil && !info.IsDir() {
+			files = append(files, path)
+		}
+	}
+	return files
+}
@@ -0,0 +1,65 @@
+package htmlcheck
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_ValidateGlob(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "pages", "blog"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "index.html"), []byte("<a></a>"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "pages", "blog", "post.html"),
+		[]byte("<a>\n<b></b></a>"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "pages", "notes.txt"), []byte("<b>"), 0644)
+
+	val := newValidator(ValidTag{Name: "a"})
+
+	results, err := val.ValidateGlob(filepath.Join(dir, "*.html"))
+	if err != nil || len(results) != 1 {
+		t.Fatal("expected one file", results, err)
+	}
+	checkErrors(t, results[filepath.Join(dir, "index.html")])
+
+	results, err = val.ValidateGlob(filepath.Join(dir, "**", "*.html"))
+	if err != nil || len(results) != 2 {
+		t.Fatal("expected two files", results, err)
+	}
+	errors := results[filepath.Join(dir, "pages", "blog", "post.html")]
+	hasReason(t, errors, InvTag)
+	if errors[0].TextPos == nil || errors[0].TextPos.Line != 2 {
+		t.Fatal("expected a line position", errors[0])
+	}
+
+	results, err = val.ValidateGlob(filepath.Join(dir, "missing", "**", "*.html"))
+	if err != nil || len(results) != 0 {
+		t.Fatal("expected no matches", results, err)
+	}
+
+	_, err = val.ValidateGlob(filepath.Join(dir, "[*.html"))
+	if err == nil {
+		t.Fatal("expected a pattern error")
+	}
+}
+
+func Test_ValidateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "page.html")
+	ioutil.WriteFile(path, []byte("<a>\n  <kk></kk></a>"), 0644)
+
+	val := newValidator(ValidTag{Name: "a"})
+	errors, err := val.ValidateFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hasReason(t, errors, InvTag)
+	if *errors[0].TextPos != (TextPos{2, 4}) {
+		t.Fatal("unexpected position", errors[0].TextPos)
+	}
+
+	_, err = val.ValidateFile(filepath.Join(t.TempDir(), "missing.html"))
+	if err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,178 @@
+package htmlcheck
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	html "github.com/BlackEspresso/htmlcheck/htmlp"
+)
+
+type formatNode struct {
+	text     string // text, comment, doctype or verbatim source
+	tagName  string
+	attrs    []html.Attribute
+	void     bool
+	children []*formatNode
+}
+
+// verbatimTags are written exactly as they are in the source.
+var verbatimTags = setOf("pre", "textarea", "script", "style")
+
+// Format validates r and, if there are no errors of SeverityError, writes
+// the document to w with every element on its own line, indented by two
+// spaces per level and all attribute values double quoted. Elements that
+// only contain text stay on one line, pre, textarea, script and style are
+// copied unchanged and white space around text is trimmed. It returns the
+// validation errors and the first error reading r or writing w.
+func (v *Validator) Format(r io.Reader,
+	w io.Writer) ([]*ValidationError, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	errors := v.ValidateHtml(bytes.NewReader(content))
+	for _, e := range errors {
+		if e.Severity == SeverityError {
+			return errors, nil
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, node := range v.parseFormatTree(content) {
+		writeFormatNode(bw, node, 0)
+	}
+	return errors, bw.Flush()
+}
+
+func (v *Validator) parseFormatTree(content []byte) []*formatNode {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	root := &formatNode{}
+	stack := []*formatNode{root}
+	z := html.NewTokenizer(bytes.NewReader(content))
+	for {
+		tokenType := z.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+		raw := string(z.Raw())
+		var token html.Token
+		if tokenType == html.StartTagToken ||
+			tokenType == html.SelfClosingTagToken {
+			token = z.Token()
+			stack = v.autoCloseNodes(stack, token.Data)
+		}
+		parent := stack[len(stack)-1]
+		switch tokenType {
+		case html.TextToken:
+			if text := strings.TrimSpace(raw); text != "" {
+				parent.children = append(parent.children,
+					&formatNode{text: text})
+			}
+		case html.CommentToken, html.DoctypeToken:
+			parent.children = append(parent.children, &formatNode{text: raw})
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if tokenType == html.StartTagToken && verbatimTags[token.Data] {
+				parent.children = append(parent.children,
+					&formatNode{text: raw + readVerbatim(z, token.Data)})
+				continue
+			}
+			node := &formatNode{tagName: token.Data, attrs: token.Attr}
+			parent.children = append(parent.children, node)
+			if tokenType == html.SelfClosingTagToken ||
+				v.isValidSelfClosingTag(token.Data) {
+				node.void = true
+			} else {
+				stack = append(stack, node)
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].tagName == string(name) {
+					stack = stack[:i]
+					break
+				}
+			}
+		}
+	}
+	return root.children
+}
+
+// autoCloseNodes pops the open nodes that list tagName in their
+// AutoClosedBy, like autoClose does for the validation.
+func (v *Validator) autoCloseNodes(stack []*formatNode,
+	tagName string) []*formatNode {
+	for len(stack) > 1 {
+		tag := v.validTags[stack[len(stack)-1].tagName]
+		if tag == nil || indexOf(tag.AutoClosedBy, tagName) == -1 {
+			break
+		}
+		stack = stack[:len(stack)-1]
+	}
+	return stack
+}
+
+// readVerbatim returns the source up to and including the end tag of
+// tagName.
+func readVerbatim(z *html.Tokenizer, tagName string) string {
+	source := ""
+	nesting := 0
+	for {
+		tokenType := z.Next()
+		if tokenType == html.ErrorToken {
+			return source
+		}
+		source += string(z.Raw())
+		name, _ := z.TagName()
+		if string(name) != tagName {
+			continue
+		}
+		switch tokenType {
+		case html.StartTagToken:
+			nesting++
+		case html.EndTagToken:
+			if nesting == 0 {
+				return source
+			}
+			nesting--
+		}
+	}
+}
+
+func writeFormatNode(w *bufio.Writer, node *formatNode, depth int) {
+	indent := strings.Repeat("  ", depth)
+	if node.tagName == "" {
+		w.WriteString(indent + node.text + "\n")
+		return
+	}
+
+	open := "<" + node.tagName
+	for _, attr := range node.attrs {
+		open += " " + attr.Key
+		if attr.Val != "" {
+			open += "=\"" + html.EscapeString(attr.Val) + "\""
+		}
+	}
+	open += ">"
+	end := "</" + node.tagName + ">"
+
+	switch {
+	case node.void:
+		w.WriteString(indent + open + "\n")
+	case len(node.children) == 0:
+		w.WriteString(indent + open + end + "\n")
+	case len(node.children) == 1 && node.children[0].tagName == "" &&
+		!strings.HasPrefix(node.children[0].text, "<"):
+		w.WriteString(indent + open + node.children[0].text + end + "\n")
+	default:
+		w.WriteString(indent + open + "\n")
+		for _, child := range node.children {
+			writeFormatNode(w, child, depth+1)
+		}
+		w.WriteString(indent + end + "\n")
+	}
+}
@@ -0,0 +1,77 @@
+package htmlcheck
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func Test_Format(t *testing.T) {
+	val := newValidator(ValidTag{Name: "div", Attrs: []string{"class"}},
+		ValidTag{Name: "p"}, ValidTag{Name: "b"}, ValidTag{Name: "pre"},
+		ValidTag{Name: "br", IsSelfClosing: true})
+
+	out := &bytes.Buffer{}
+	errors, err := val.Format(strings.NewReader("<div class='a'><p>Hi <b>there</b></p><br>\n"+
+		"<pre>  keep\n    <b>me</b></pre><!-- note --></div>"), out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkErrors(t, errors)
+
+	expected := `<div class="a">
+  <p>
+    Hi
+    <b>there</b>
+  </p>
+  <br>
+  <pre>  keep
+    <b>me</b></pre>
+  <!-- note -->
+</div>
+`
+	if out.String() != expected {
+		t.Fatal("unexpected output\n" + out.String())
+	}
+
+	out.Reset()
+	errors, _ = val.Format(strings.NewReader("<div><p></div>"), out)
+	hasReason(t, errors, InvNotProperlyClosed)
+	if out.Len() != 0 {
+		t.Fatal("malformed input should not be formatted", out.String())
+	}
+
+	_, err = val.Format(iotest.ErrReader(io.ErrUnexpectedEOF), out)
+	if err != io.ErrUnexpectedEOF {
+		t.Fatal("expected the read error", err)
+	}
+}
+
+func Test_FormatOptionalEndTags(t *testing.T) {
+	val := newValidator(ValidTag{Name: "ul"}, ValidTag{Name: "b"},
+		ValidTag{Name: "li", AutoClosedBy: []string{"li"}})
+
+	out := &bytes.Buffer{}
+	errors, err := val.Format(strings.NewReader(
+		"<ul><li>a<li><b>b</b></ul><ul><li>c</ul>"), out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkErrors(t, errors)
+
+	expected := `<ul>
+  <li>a</li>
+  <li>
+    <b>b</b>
+  </li>
+</ul>
+<ul>
+  <li>c</li>
+</ul>
+`
+	if out.String() != expected {
+		t.Fatal("unexpected output\n" + out.String())
+	}
+}
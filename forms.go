@@ -0,0 +1,209 @@
+package htmlcheck
+
+import (
+	"strings"
+
+	html "github.com/BlackEspresso/htmlcheck/htmlp"
+)
+
+func attrValue(token html.Token, key string) (string, bool) {
+	for _, attr := range token.Attr {
+		if attr.Key == key {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+type labelRef struct {
+	id      string
+	pos     Span
+	textPos *TextPos
+}
+
+// idTarget is the element an id was first seen on.
+type idTarget struct {
+	tagName   string
+	labelable bool
+}
+
+type openLabel struct {
+	pos      Span
+	textPos  *TextPos
+	elements int
+	controls int
+}
+
+// checkForm runs the form related rules enabled by CheckForms on a tag.
+func (v *Validator) checkForm(s *validation, token html.Token, pos Span) {
+	if token.Type == html.EndTagToken {
+		if token.Data == "label" && s.label != nil {
+			if s.label.elements > 0 && s.label.controls == 0 {
+				v.reportAt(s, "label", "", "", s.label.pos,
+					s.label.textPos, InvNonLabelableTarget)
+			}
+			s.label = nil
+		}
+		return
+	}
+
+	if id, ok := attrValue(token, "id"); ok {
+		if s.ids == nil {
+			s.ids = map[string]idTarget{}
+		}
+		if _, seen := s.ids[id]; !seen {
+			s.ids[id] = idTarget{token.Data, isLabelable(token)}
+		}
+	}
+	if s.label != nil {
+		s.label.elements++
+		if isLabelable(token) {
+			s.label.controls++
+		}
+	}
+
+	switch token.Data {
+	case "form":
+		if s.countOf("form") > 1 {
+			cError := v.report(s, token.Data, "", "", pos, InvNestedForm)
+			if cError != nil {
+				cError.Note = "browsers ignore the inner form tag"
+			}
+		}
+		v.checkFormConfig(s, token, pos)
+	case "label":
+		if id, ok := attrValue(token, "for"); ok {
+			s.labelFors = append(s.labelFors,
+				labelRef{id, pos, s.textPos(pos.Start)})
+		} else if token.Type == html.StartTagToken {
+			s.label = &openLabel{pos: pos, textPos: s.textPos(pos.Start)}
+		}
+	case "input", "select", "textarea":
+		if token.Data == "input" {
+			v.checkRadio(s, token, pos)
+		}
+		if s.indexOf("form") == -1 || hasAttr(token, "name") {
+			return
+		}
+		inputType, _ := attrValue(token, "type")
+		switch strings.ToLower(strings.TrimSpace(inputType)) {
+		case "button", "submit", "reset":
+			return
+		}
+		cError := v.report(s, token.Data, "name", "", pos, InvMissingName)
+		if cError != nil {
+			cError.Note = "controls without a name are not submitted"
+		}
+	}
+}
+
+// checkFormConfig reports an enctype on a form that is submitted with GET,
+// where the data always goes into the URL and the enctype is ignored.
+func (v *Validator) checkFormConfig(s *validation, token html.Token,
+	pos Span) {
+	enctype, ok := attrValue(token, "enctype")
+	enctype = strings.ToLower(strings.TrimSpace(enctype))
+	if !ok || enctype == "" || enctype == "application/x-www-form-urlencoded" {
+		return
+	}
+	method, _ := attrValue(token, "method")
+	method = strings.ToLower(strings.TrimSpace(method))
+	if method != "" && method != "get" {
+		return
+	}
+	cError := v.report(s, token.Data, "enctype", enctype, pos,
+		InvBadFormConfig)
+	if cError != nil {
+		cError.Note = "GET submissions ignore enctype, " + enctype +
+			" needs method=\"post\""
+	}
+}
+
+// checkRadio reports a checked radio button in a group, identified by its
+// name, that already has a checked one.
+func (v *Validator) checkRadio(s *validation, token html.Token, pos Span) {
+	inputType, _ := attrValue(token, "type")
+	name, ok := attrValue(token, "name")
+	if !ok || !hasAttr(token, "checked") ||
+		!strings.EqualFold(strings.TrimSpace(inputType), "radio") {
+		return
+	}
+	if s.checkedRadios == nil {
+		s.checkedRadios = map[string]bool{}
+	}
+	if s.checkedRadios[name] {
+		cError := v.report(s, token.Data, "checked", name, pos,
+			InvMultipleChecked)
+		if cError != nil {
+			cError.Note = "group '" + name + "' already has a checked " +
+				"radio button"
+		}
+		return
+	}
+	s.checkedRadios[name] = true
+}
+
+// isLabelable reports whether a label can be associated with token. Hidden
+// inputs are not labelable.
+func isLabelable(token html.Token) bool {
+	if token.Data == "input" {
+		inputType, _ := attrValue(token, "type")
+		return !strings.EqualFold(strings.TrimSpace(inputType), "hidden")
+	}
+	return LabelableElements[token.Data]
+}
+
+// checkLabelTargets reports labels whose for attribute names an element
+// that can't be labelled. Unknown ids are left to other rules.
+func (v *Validator) checkLabelTargets(s *validation) {
+	for _, ref := range s.labelFors {
+		target, ok := s.ids[ref.id]
+		if ok && !target.labelable {
+			cError := v.reportAt(s, "label", "for", ref.id, ref.pos,
+				ref.textPos, InvNonLabelableTarget)
+			if cError != nil {
+				cError.Note = "'" + ref.id + "' is a '" + target.tagName +
+					"', labels can only point to form controls"
+				if target.tagName == "input" {
+					cError.Note = "'" + ref.id + "' is a hidden input, " +
+						"labels can only point to visible form controls"
+				}
+			}
+			if v.stopped(s) {
+				return
+			}
+		}
+	}
+}
+
+// IsValidAutocomplete reports whether value follows the autofill token
+// grammar: "on", "off", or an optional section-*, an optional shipping or
+// billing, a field name (contact fields may be preceded by a contact type)
+// and an optional trailing webauthn.
+func IsValidAutocomplete(value string) bool {
+	tokens := strings.Fields(strings.ToLower(value))
+	if len(tokens) == 0 {
+		return true
+	}
+	if len(tokens) == 1 && (tokens[0] == "on" || tokens[0] == "off") {
+		return true
+	}
+	if tokens[len(tokens)-1] == "webauthn" {
+		tokens = tokens[:len(tokens)-1]
+	}
+	if len(tokens) > 0 && strings.HasPrefix(tokens[0], "section-") {
+		tokens = tokens[1:]
+	}
+	if len(tokens) > 0 && (tokens[0] == "shipping" || tokens[0] == "billing") {
+		tokens = tokens[1:]
+	}
+	switch len(tokens) {
+	case 1:
+		return AutocompleteFields[tokens[0]] ||
+			AutocompleteContactFields[tokens[0]]
+	case 2:
+		return AutocompleteContactTypes[tokens[0]] &&
+			AutocompleteContactFields[tokens[1]]
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package htmlcheck
+
+import (
+	"testing"
+)
+
+func newFormValidator() *Validator {
+	val := newValidator(ValidTag{Name: "form", Attrs: []string{"method", "enctype"}},
+		ValidTag{Name: "input", Attrs: []string{"name", "type", "value"},
+			IsSelfClosing: true},
+		ValidTag{Name: "select", Attrs: []string{"name"}},
+		ValidTag{Name: "textarea", Attrs: []string{"name"}})
+	val.CheckForms = true
+	return val
+}
+
+func Test_FormControlNames(t *testing.T) {
+	val := newFormValidator()
+
+	errors := val.ValidateHtmlString("<form><input name='q'></form>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<input><textarea></textarea>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<form><input type='submit'></form>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<form><input type='text'></form>")
+	hasReason(t, errors, InvMissingName)
+
+	errors = val.ValidateHtmlString("<form><select></select></form>")
+	hasReason(t, errors, InvMissingName)
+}
+
+func Test_Autocomplete(t *testing.T) {
+	valid := []string{"on", "off", "new-password", "shipping given-name",
+		"section-blue billing email", "work tel", "username webauthn", ""}
+	for _, value := range valid {
+		if !IsValidAutocomplete(value) {
+			t.Error("should be valid:", value)
+		}
+	}
+	invalid := []string{"yes", "on off", "work given-name", "email shipping",
+		"new_password"}
+	for _, value := range invalid {
+		if IsValidAutocomplete(value) {
+			t.Error("should be invalid:", value)
+		}
+	}
+
+	val := newValidator(ValidTag{Name: "input",
+		Attrs: []string{"autocomplete"}, IsSelfClosing: true})
+	val.CheckAutocomplete = true
+
+	errors := val.ValidateHtmlString("<input autocomplete='on'>" +
+		"<input autocomplete='new-password'>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<input autocomplete='password'>")
+	hasReason(t, errors, InvBadAutocomplete)
+}
+
+func Test_LabelTarget(t *testing.T) {
+	val := newValidator(ValidTag{Name: "label", Attrs: []string{"for"}},
+		ValidTag{Name: "div", Attrs: []string{"id"}},
+		ValidTag{Name: "span"},
+		ValidTag{Name: "input", Attrs: []string{"id", "type"}, IsSelfClosing: true})
+	val.CheckForms = true
+
+	errors := val.ValidateHtmlString(`<label for="q">Search</label><input id="q">`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<label><span>Search</span><input></label>`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<label for="q">Search</label><div id="q"></div>`)
+	hasReason(t, errors, InvNonLabelableTarget)
+
+	errors = val.ValidateHtmlString(`<label><div>Search</div></label>`)
+	hasReason(t, errors, InvNonLabelableTarget)
+
+	errors = val.ValidateHtmlString(
+		`<label for="q">Search</label><input type="hidden" id="q">`)
+	hasReason(t, errors, InvNonLabelableTarget)
+
+	errors = val.ValidateHtmlString(
+		`<label><span>Search</span><input type="Hidden"></label>`)
+	hasReason(t, errors, InvNonLabelableTarget)
+}
+
+func Test_FormConfig(t *testing.T) {
+	val := newFormValidator()
+
+	errors := val.ValidateHtmlString(`<form method="post" enctype="multipart/form-data"></form>` +
+		`<form method="get"></form><form enctype="application/x-www-form-urlencoded"></form>`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<form method="get" enctype="multipart/form-data"></form>`)
+	hasReason(t, errors, InvBadFormConfig)
+
+	errors = val.ValidateHtmlString(`<form enctype="multipart/form-data"></form>`)
+	hasReason(t, errors, InvBadFormConfig)
+}
+
+func Test_MultipleChecked(t *testing.T) {
+	val := newValidator(ValidTag{Name: "input", Attrs: []string{"type", "name", "value", "checked"},
+		IsSelfClosing: true})
+	val.CheckForms = true
+
+	errors := val.ValidateHtmlString(`<input type="radio" name="size" value="s" checked>` +
+		`<input type="radio" name="size" value="m"><input type="radio" name="color" value="red" checked>`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<input type="radio" name="size" value="s" checked>` +
+		`<input type="radio" name="size" value="m" checked>`)
+	hasReason(t, errors, InvMultipleChecked)
+}
+
+func Test_NestedForm(t *testing.T) {
+	val := newFormValidator()
+
+	errors := val.ValidateHtmlString("<form></form><form></form>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<form><form></form></form>")
+	hasReason(t, errors, InvNestedForm)
+}
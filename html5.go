@@ -0,0 +1,157 @@
+package htmlcheck
+
+import (
+	"sort"
+)
+
+// html5GlobalAttrs are the attributes allowed on every HTML5 element.
+// aria-*, data-* and on* event handlers are matched by a pattern.
+var html5GlobalAttrs = []string{"accesskey", "autocapitalize", "autofocus",
+	"class", "contenteditable", "dir", "draggable", "enterkeyhint", "hidden",
+	"id", "inert", "inputmode", "is", "itemid", "itemprop", "itemref",
+	"itemscope", "itemtype", "lang", "nonce", "popover", "role", "slot",
+	"spellcheck", "style", "tabindex", "title", "translate"}
+
+// html5Attrs are the element specific attributes of the HTML5 elements.
+var html5Attrs = map[string][]string{
+	"a": {"href", "target", "download", "ping", "rel", "hreflang", "type",
+		"referrerpolicy"},
+	"abbr": nil, "address": nil, "article": nil, "aside": nil,
+	"area": {"alt", "coords", "shape", "href", "target", "download", "ping",
+		"rel", "referrerpolicy"},
+	"audio": {"src", "crossorigin", "preload", "autoplay", "loop", "muted",
+		"controls"},
+	"b": nil, "base": {"href", "target"}, "bdi": nil, "bdo": nil,
+	"blockquote": {"cite"}, "body": nil, "br": nil,
+	"button": {"disabled", "form", "formaction", "formenctype",
+		"formmethod", "formnovalidate", "formtarget", "name", "popovertarget",
+		"popovertargetaction", "type", "value"},
+	"canvas": {"width", "height"}, "caption": nil, "cite": nil, "code": nil,
+	"col": {"span"}, "colgroup": {"span"}, "data": {"value"},
+	"datalist": nil, "dd": nil, "del": {"cite", "datetime"},
+	"details": {"name", "open"}, "dfn": nil, "dialog": {"open"}, "div": nil,
+	"dl": nil, "dt": nil, "em": nil,
+	"embed":    {"src", "type", "width", "height"},
+	"fieldset": {"disabled", "form", "name"}, "figcaption": nil,
+	"figure": nil, "footer": nil,
+	"form": {"accept-charset", "action", "autocomplete", "enctype",
+		"method", "name", "novalidate", "rel", "target"},
+	"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+	"head": nil, "header": nil, "hgroup": nil, "hr": nil, "html": nil,
+	"i": nil,
+	"iframe": {"src", "srcdoc", "name", "sandbox", "allow",
+		"allowfullscreen", "width", "height", "referrerpolicy", "loading"},
+	"img": {"alt", "src", "srcset", "sizes", "crossorigin", "usemap",
+		"ismap", "width", "height", "referrerpolicy", "decoding", "loading",
+		"fetchpriority"},
+	"input": {"accept", "alt", "autocomplete", "checked", "dirname",
+		"disabled", "form", "formaction", "formenctype", "formmethod",
+		"formnovalidate", "formtarget", "height", "list", "max",
+		"maxlength", "min", "minlength", "multiple", "name", "pattern",
+		"placeholder", "popovertarget", "popovertargetaction", "readonly",
+		"required", "size", "src", "step", "type", "value", "width"},
+	"ins": {"cite", "datetime"}, "kbd": nil, "label": {"for"},
+	"legend": nil, "li": {"value"},
+	"link": {"href", "crossorigin", "rel", "as", "media", "hreflang",
+		"type", "sizes", "imagesrcset", "imagesizes", "referrerpolicy",
+		"integrity", "blocking", "color", "disabled", "fetchpriority"},
+	"main": nil, "map": {"name"}, "mark": nil, "menu": nil,
+	"meta":  {"name", "http-equiv", "content", "charset", "media"},
+	"meter": {"value", "min", "max", "low", "high", "optimum"},
+	"nav":   nil, "noscript": nil,
+	"object":   {"data", "type", "name", "form", "width", "height"},
+	"ol":       {"reversed", "start", "type"},
+	"optgroup": {"disabled", "label"},
+	"option":   {"disabled", "label", "selected", "value"},
+	"output":   {"for", "form", "name"}, "p": nil, "picture": nil,
+	"pre": nil, "progress": {"value", "max"}, "q": {"cite"}, "rp": nil,
+	"rt": nil, "ruby": nil, "s": nil, "samp": nil,
+	"script": {"src", "type", "nomodule", "async", "defer", "crossorigin",
+		"integrity", "referrerpolicy", "blocking", "fetchpriority"},
+	"search": nil, "section": nil,
+	"select": {"autocomplete", "disabled", "form", "multiple", "name",
+		"required", "size"},
+	"slot": {"name"}, "small": nil,
+	"source": {"type", "media", "src", "srcset", "sizes", "width",
+		"height"},
+	"span": nil, "strong": nil, "style": {"media", "blocking"}, "sub": nil,
+	"summary": nil, "sup": nil, "table": nil, "tbody": nil,
+	"td": {"colspan", "rowspan", "headers"},
+	"template": {"shadowrootmode", "shadowrootdelegatesfocus",
+		"shadowrootclonable", "shadowrootserializable"},
+	"textarea": {"autocomplete", "cols", "dirname", "disabled", "form",
+		"maxlength", "minlength", "name", "placeholder", "readonly",
+		"required", "rows", "wrap"},
+	"tfoot": nil, "th": {"colspan", "rowspan", "headers", "scope", "abbr"},
+	"thead": nil, "time": {"datetime"}, "title": nil, "tr": nil,
+	"track": {"default", "kind", "label", "src", "srclang"}, "u": nil,
+	"ul": nil, "var": nil,
+	"video": {"src", "crossorigin", "poster", "preload", "autoplay",
+		"playsinline", "loop", "muted", "controls", "width", "height"},
+	"wbr": nil,
+}
+
+// html5VoidElements are the HTML5 elements without an end tag.
+var html5VoidElements = setOf("area", "base", "br", "col", "embed", "hr",
+	"img", "input", "link", "meta", "source", "track", "wbr")
+
+// html5ClosesP are the start tags that close an open p element.
+var html5ClosesP = []string{"address", "article", "aside", "blockquote",
+	"details", "dialog", "div", "dl", "fieldset", "figcaption", "figure",
+	"footer", "form", "h1", "h2", "h3", "h4", "h5", "h6", "header",
+	"hgroup", "hr", "main", "menu", "nav", "ol", "p", "pre", "search",
+	"section", "table", "ul"}
+
+// html5AutoClosedBy are the elements whose end tag may be left out before
+// one of the listed start tags.
+var html5AutoClosedBy = map[string][]string{
+	"head":     {"body"},
+	"li":       {"li"},
+	"dt":       {"dt", "dd"},
+	"dd":       {"dt", "dd"},
+	"p":        html5ClosesP,
+	"rt":       {"rt", "rp"},
+	"rp":       {"rt", "rp"},
+	"optgroup": {"optgroup"},
+	"option":   {"option", "optgroup"},
+	"thead":    {"tbody", "tfoot"},
+	"tbody":    {"tbody", "tfoot"},
+	"tr":       {"tr", "tbody", "tfoot"},
+	"td":       {"td", "th", "tr", "tbody", "tfoot"},
+	"th":       {"td", "th", "tr", "tbody", "tfoot"},
+}
+
+// html5OptionalEndTags are the other elements whose end tag may be left
+// out, closed by the end tag of their parent or the end of the document.
+var html5OptionalEndTags = setOf("html", "body", "colgroup", "tfoot")
+
+// HTML5Tags returns the standard HTML5 elements with their attributes and
+// a global tag with the global attributes, sorted by name so the global tag
+// comes first. Each call returns new tags.
+func HTML5Tags() []*ValidTag {
+	tags := []*ValidTag{{Name: "", Attrs: html5GlobalAttrs,
+		AttrRegEx: `^(aria-[a-z]+|data-[a-z0-9_.:-]+|on[a-z]+)$`}}
+	names := make([]string, 0, len(html5Attrs))
+	for name := range html5Attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		tags = append(tags, &ValidTag{Name: name, Attrs: html5Attrs[name],
+			IsSelfClosing:  html5VoidElements[name],
+			OptionalEndTag: html5OptionalEndTags[name],
+			AutoClosedBy:   html5AutoClosedBy[name]})
+	}
+	return tags
+}
+
+// HTML5Validator returns a validator that accepts the standard HTML5
+// elements and attributes, allowing the end tags HTML5 makes optional to be
+// left out.
+func HTML5Validator() *Validator {
+	v := &Validator{}
+	if err := v.AddValidTags(HTML5Tags()); err != nil {
+		panic("htmlcheck: invalid HTML5 tags: " + err.Error())
+	}
+	return v
+}
@@ -0,0 +1,45 @@
+package htmlcheck
+
+import (
+	"testing"
+)
+
+func Test_HTML5Validator(t *testing.T) {
+	val := HTML5Validator()
+
+	errors := val.ValidateHtmlString(`<!DOCTYPE html><html lang="en"><head>` +
+		`<meta charset="utf-8"><title>Home</title><link rel="stylesheet" href="a.css"></head>` +
+		`<body><main id="m" data-page="home" aria-label="Main"><img src="a.png" alt="">` +
+		`<br><input type="text" name="q"><hr><button type="button" onclick="go()">Go</button>` +
+		`</main></body></html>`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<blink>old</blink>`)
+	hasReason(t, errors, InvTag)
+
+	errors = val.ValidateHtmlString(`<div href="/"></div>`)
+	hasReason(t, errors, InvAttribute)
+
+	errors = val.ValidateHtmlString(`<ul><li>a<li>b</ul><p>one<p>two` +
+		`<div><table><tr><th>a<td>b<tr><td>c</table></div>` +
+		`<select><option>a<optgroup><option>b</select>`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<ul><li><b>a</ul>`)
+	hasReason(t, errors, InvNotProperlyClosed)
+
+	for name := range html5VoidElements {
+		if !val.IsValidSelfClosingTag(name) {
+			t.Fatal("should be void", name)
+		}
+	}
+}
+
+func Test_HTML5TagsOrder(t *testing.T) {
+	tags := HTML5Tags()
+	for i := 1; i < len(tags); i++ {
+		if tags[i-1].Name >= tags[i].Name {
+			t.Fatal("tags are not sorted", tags[i-1].Name, tags[i].Name)
+		}
+	}
+}
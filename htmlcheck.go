@@ -1,14 +1,22 @@
 package htmlcheck
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"io/ioutil"
-	"log"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/text/encoding"
 
 	//"golang.org/x/net/html"
 	html "github.com/BlackEspresso/htmlcheck/htmlp"
@@ -17,22 +25,173 @@ import (
 type ErrorReason int
 
 const (
-	InvTag                 ErrorReason = 0
-	InvAttribute           ErrorReason = 1
-	InvClosedBeforeOpened  ErrorReason = 2
-	InvNotProperlyClosed   ErrorReason = 3
-	InvDuplicatedAttribute ErrorReason = 4
-	InvEOF                 ErrorReason = 5
+	InvTag                    ErrorReason = 0
+	InvAttribute              ErrorReason = 1
+	InvClosedBeforeOpened     ErrorReason = 2
+	InvNotProperlyClosed      ErrorReason = 3
+	InvDuplicatedAttribute    ErrorReason = 4
+	InvEOF                    ErrorReason = 5
+	InvUnescapedInAttr        ErrorReason = 6
+	InvExcessiveSelfNesting   ErrorReason = 7
+	InvImplicitButtonType     ErrorReason = 8
+	InvDisallowedComment      ErrorReason = 9
+	InvCharsetNotFirst        ErrorReason = 10
+	InvMissingName            ErrorReason = 11
+	InvBadRel                 ErrorReason = 12
+	InvBadViewport            ErrorReason = 13
+	InvMissingRecommendedAttr ErrorReason = 14
+	InvBadDatetime            ErrorReason = 15
+	InvAttributeAlias         ErrorReason = 16
+	InvEndTagForVoid          ErrorReason = 17
+	InvAttrQuoteStyle         ErrorReason = 18
+	InvBadAutocomplete        ErrorReason = 19
+	InvMultipleMain           ErrorReason = 20
+	InvPositiveTabindex       ErrorReason = 21
+	InvBadDataAttrValue       ErrorReason = 22
+	InvBadHttpEquiv           ErrorReason = 23
+	InvBadSourceContext       ErrorReason = 24
+	InvNonLabelableTarget     ErrorReason = 25
+	InvLegacyDoctype          ErrorReason = 26
+	InvDoctypeForm            ErrorReason = 27
+	InvContentModel           ErrorReason = 28
+	InvMalformedAttrValue     ErrorReason = 29
+	InvUnknownAria            ErrorReason = 30
+	InvAmbiguousLandmark      ErrorReason = 31
+	InvMaxDepthExceeded       ErrorReason = 32
+	InvBadFormConfig          ErrorReason = 33
+	InvBadTrack               ErrorReason = 34
+	InvMissingNonce           ErrorReason = 35
+	InvMissingTitle           ErrorReason = 36
+	InvMultipleTitles         ErrorReason = 37
+	InvEmptyTitle             ErrorReason = 38
+	InvBadLoading             ErrorReason = 39
+	InvMissingLoading         ErrorReason = 40
+	InvExcessiveNesting       ErrorReason = 41
+	InvMultipleChecked        ErrorReason = 42
+	InvNestedForm             ErrorReason = 43
+	InvLargeInlineData        ErrorReason = 44
+	InvMissingAttribute       ErrorReason = 45
+	InvAttributeValue         ErrorReason = 46
+	InvMissingScope           ErrorReason = 47
+	InvBadScope               ErrorReason = 48
+	InvIllegalNesting         ErrorReason = 49
+	InvMissingAttributeValue  ErrorReason = 50
+	InvMalformedComment       ErrorReason = 51
+	InvMissingDoctype         ErrorReason = 52
+	InvDocumentStructure      ErrorReason = 53
+	InvDuplicateId            ErrorReason = 54
+	InvDeprecatedTag          ErrorReason = 55
+	InvEventHandler           ErrorReason = 56
+	InvUnsafeURL              ErrorReason = 57
+	InvSelfClosingNonVoid     ErrorReason = 58
+	InvTooManyAttributes      ErrorReason = 59
 )
 
+// InvEndTagOnVoidElement is another name for InvEndTagForVoid, the reason
+// reported for end tags like </br> when CheckVoidEndTags is set.
+const InvEndTagOnVoidElement = InvEndTagForVoid
+
+type Severity int
+
+const (
+	SeverityError   Severity = 0
+	SeverityWarning Severity = 1
+)
+
+var warningReasons = map[ErrorReason]bool{
+	InvImplicitButtonType:     true,
+	InvMissingRecommendedAttr: true,
+	InvAttributeAlias:         true,
+	InvEndTagForVoid:          true,
+	InvAttrQuoteStyle:         true,
+	InvPositiveTabindex:       true,
+	InvAmbiguousLandmark:      true,
+	InvMissingLoading:         true,
+	InvLargeInlineData:        true,
+	InvMissingScope:           true,
+	InvDeprecatedTag:          true,
+	InvSelfClosingNonVoid:     true,
+}
+
+// String returns the stable name of the reason, like "InvalidTag".
+func (r ErrorReason) String() string {
+	if name, ok := reasonNames[r]; ok {
+		return name
+	}
+	return "ErrorReason(" + strconv.Itoa(int(r)) + ")"
+}
+
+var reasonNames = map[ErrorReason]string{
+	InvTag:                    "InvalidTag",
+	InvAttribute:              "InvalidAttribute",
+	InvClosedBeforeOpened:     "ClosedBeforeOpened",
+	InvNotProperlyClosed:      "NotProperlyClosed",
+	InvDuplicatedAttribute:    "DuplicatedAttribute",
+	InvEOF:                    "EOF",
+	InvUnescapedInAttr:        "UnescapedInAttribute",
+	InvExcessiveSelfNesting:   "ExcessiveSelfNesting",
+	InvImplicitButtonType:     "ImplicitButtonType",
+	InvDisallowedComment:      "DisallowedComment",
+	InvCharsetNotFirst:        "CharsetNotFirst",
+	InvMissingName:            "MissingName",
+	InvBadRel:                 "BadRel",
+	InvBadViewport:            "BadViewport",
+	InvMissingRecommendedAttr: "MissingRecommendedAttribute",
+	InvBadDatetime:            "BadDatetime",
+	InvAttributeAlias:         "AttributeAlias",
+	InvEndTagForVoid:          "EndTagForVoid",
+	InvAttrQuoteStyle:         "AttributeQuoteStyle",
+	InvBadAutocomplete:        "BadAutocomplete",
+	InvMultipleMain:           "MultipleMain",
+	InvPositiveTabindex:       "PositiveTabindex",
+	InvBadDataAttrValue:       "BadDataAttributeValue",
+	InvBadHttpEquiv:           "BadHttpEquiv",
+	InvBadSourceContext:       "BadSourceContext",
+	InvNonLabelableTarget:     "NonLabelableTarget",
+	InvLegacyDoctype:          "LegacyDoctype",
+	InvDoctypeForm:            "DoctypeForm",
+	InvContentModel:           "ContentModel",
+	InvMalformedAttrValue:     "MalformedAttrValue",
+	InvUnknownAria:            "UnknownAria",
+	InvAmbiguousLandmark:      "AmbiguousLandmark",
+	InvMaxDepthExceeded:       "MaxDepthExceeded",
+	InvBadFormConfig:          "BadFormConfig",
+	InvBadTrack:               "BadTrack",
+	InvMissingNonce:           "MissingNonce",
+	InvMissingTitle:           "MissingTitle",
+	InvMultipleTitles:         "MultipleTitles",
+	InvEmptyTitle:             "EmptyTitle",
+	InvBadLoading:             "BadLoading",
+	InvMissingLoading:         "MissingLoading",
+	InvExcessiveNesting:       "ExcessiveNesting",
+	InvMultipleChecked:        "MultipleChecked",
+	InvNestedForm:             "NestedForm",
+	InvLargeInlineData:        "LargeInlineData",
+	InvMissingAttribute:       "MissingAttribute",
+	InvAttributeValue:         "InvalidAttributeValue",
+	InvMissingScope:           "MissingScope",
+	InvBadScope:               "BadScope",
+	InvIllegalNesting:         "IllegalNesting",
+	InvMissingAttributeValue:  "MissingAttributeValue",
+	InvMalformedComment:       "MalformedComment",
+	InvMissingDoctype:         "MissingDoctype",
+	InvDocumentStructure:      "DocumentStructure",
+	InvDuplicateId:            "DuplicateId",
+	InvDeprecatedTag:          "DeprecatedTag",
+	InvEventHandler:           "EventHandler",
+	InvUnsafeURL:              "UnsafeURL",
+	InvSelfClosingNonVoid:     "SelfClosingNonVoid",
+	InvTooManyAttributes:      "TooManyAttributes",
+}
+
 type Span struct {
-	Start int
-	End   int
+	Start int `json:"start"`
+	End   int `json:"end"`
 }
 
 type TextPos struct {
-	Line   int
-	Column int
+	Line   int `json:"line"`
+	Column int `json:"column"`
 }
 
 type ErrorCallback func(tagName string, attributeName string,
@@ -50,14 +209,42 @@ type ValidTag struct {
 	Groups         []string
 	AttrStartsWith string
 	IsSelfClosing  bool
+	MaxSelfNesting int
+	// RecommendedAttrs are allowed and reported as a warning when missing.
+	RecommendedAttrs []string
+	// RequiredAttrs are allowed and reported as an error when missing.
+	RequiredAttrs []string
+	// ValueAttrs are allowed and reported when written without a value,
+	// like <a href>. With RejectEmptyValues, href="" is reported too.
+	ValueAttrs []string
+	// Deprecated tags are valid but reported as a warning.
+	Deprecated bool
+	// OptionalEndTag elements may be left open and are closed by the end
+	// tag of an ancestor or the end of the document.
+	OptionalEndTag bool
+	// AutoClosedBy lists start tags that close the element when it is the
+	// innermost open one, like li for li. It implies OptionalEndTag.
+	AutoClosedBy []string
+	// AllowedParents limits the elements the tag may be a child of. Void
+	// and transparent elements in between are skipped. Empty allows any.
+	AllowedParents []string
+	// AttrValueRegEx maps attribute names to a pattern their values have
+	// to match.
+	AttrValueRegEx map[string]string
 }
 
 type ValidationError struct {
-	TagName       string
-	AttributeName string
-	Reason        ErrorReason
-	Pos           Span
-	TextPos       *TextPos
+	TagName        string
+	AttributeName  string
+	AttributeValue string
+	// ExpectedTag is the end tag that was expected instead of the one
+	// found, for errors caused by an end tag.
+	ExpectedTag string
+	Reason      ErrorReason
+	Pos         Span
+	TextPos     *TextPos
+	Severity    Severity
+	Note        string
 }
 
 type TagsFile struct {
@@ -65,13 +252,86 @@ type TagsFile struct {
 	Tags   []*ValidTag
 }
 
+// A Validator can be used by multiple goroutines at once. AddValidTags,
+// AddGroups and the Register methods may be called while validations run
+// and wait for them to finish; the exported fields must not be changed
+// concurrently. Callbacks, including the one of ValidateHtmlStream, run
+// while the configuration is locked for reading: they must not call
+// methods of the same validator, which can deadlock once a writer waits.
 type Validator struct {
-	validTagMap          map[string]map[string]bool
-	validSelfClosingTags map[string]bool
-	errorCallback        ErrorCallback
-	StopAfterFirstError  bool
-	validTags            map[string]*ValidTag
-	validGroups          map[string]*TagGroup
+	validTagMap            map[string]map[string]bool
+	validSelfClosingTags   map[string]bool
+	errorCallback          ErrorCallback
+	contextCallback        ContextCallback
+	closeRules             map[string][]CloseRule
+	StopAfterFirstError    bool
+	Mode                   Mode
+	MaxErrors              int
+	TreatWarningsAsErrors  bool
+	Severities             map[ErrorReason]Severity
+	CheckAttrEscaping      bool
+	CheckMalformedAttrs    bool
+	CheckButtonType        bool
+	RequireScriptNonce     bool
+	BlockEventHandlers     bool
+	CheckURLs              bool
+	URLAttrs               []string
+	AllowedSchemes         []string
+	AllowedComment         *regexp.Regexp
+	DisallowedComment      *regexp.Regexp
+	CheckComments          bool
+	CheckCharsetFirst      bool
+	CheckForms             bool
+	CheckUniqueIds         bool
+	UniqueAttr             string
+	Profile                bool
+	CheckRel               bool
+	SkipInside             []string
+	CheckViewport          bool
+	CheckHttpEquiv         bool
+	CheckTitle             bool
+	RequireTitle           bool
+	CheckDatetime          bool
+	AttrAliases            map[string]string
+	WarnOnAttrAlias        bool
+	SuggestAttributes      bool
+	CheckVoidEndTags       bool
+	CheckSelfClosingSyntax bool
+	RequireDoubleQuotes    bool
+	RejectEmptyValues      bool
+	CheckAutocomplete      bool
+	CheckA11y              bool
+	CheckAriaNames         bool
+	CheckSourceContext     bool
+	CheckTracks            bool
+	CheckLoading           bool
+	LazyLoadMinArea        int
+	MaxInlineDataSize      int
+	CheckDoctype           bool
+	DoctypeForm            string
+	RequireDoctype         bool
+	AllowedDoctypes        []string
+	CheckContentCategories bool
+	UnknownTagsAreVoid     bool
+	AllowUnknownTags       bool
+	HardMaxDepth           int
+	MaxDepth               int
+	MaxAttrsPerTag         int
+	MaxChainDepth          map[string]int
+	MeaningfulContent      func(text string) bool
+	timings                timingCounters
+	mu                     sync.RWMutex
+	validTags              map[string]*ValidTag
+	validGroups            map[string]*TagGroup
+	attrValuePatterns      map[string]map[string]*regexp.Regexp
+	attrNamePatterns       map[string]*regexp.Regexp
+	hasValueAttrs          bool
+	namespaces             map[string]bool
+	disabledReasons        map[ErrorReason]bool
+	hasAutoClose           bool
+	globalAttrs            map[string]bool
+	globalAttrPatterns     []*regexp.Regexp
+	dataAttrs              map[string]*regexp.Regexp
 }
 
 func (e *ValidationError) Error() string {
@@ -81,12 +341,124 @@ func (e *ValidationError) Error() string {
 		text = "tag '" + e.TagName + "' is not valid"
 	case InvAttribute:
 		text = "invalid attribute '" + e.AttributeName + "' in tag '" + e.TagName + "'"
+		text += e.valueText()
 	case InvClosedBeforeOpened:
 		text = "'" + e.TagName + "' closed before opened."
 	case InvNotProperlyClosed:
 		text = "tag '" + e.TagName + "' is never closed"
 	case InvDuplicatedAttribute:
 		text = "duplicated attribute '" + e.AttributeName + "' in '" + e.TagName + "'"
+		text += e.valueText()
+	case InvUnescapedInAttr:
+		text = "unescaped character in attribute '" + e.AttributeName + "' in tag '" + e.TagName + "'"
+	case InvExcessiveSelfNesting:
+		text = "tag '" + e.TagName + "' is nested in itself too often"
+	case InvImplicitButtonType:
+		text = "tag '" + e.TagName + "' has no explicit type"
+	case InvDisallowedComment:
+		text = "comment is not allowed"
+	case InvCharsetNotFirst:
+		text = "charset declaration is not the first element in 'head'"
+	case InvMissingName:
+		text = "form control '" + e.TagName + "' has no name"
+	case InvBadRel:
+		text = "unknown link type in attribute '" + e.AttributeName + "' in tag '" + e.TagName + "'"
+	case InvBadViewport:
+		text = "invalid viewport in tag '" + e.TagName + "'"
+	case InvMissingRecommendedAttr:
+		text = "recommended attribute '" + e.AttributeName + "' missing in tag '" + e.TagName + "'"
+	case InvBadDatetime:
+		text = "invalid datetime in attribute '" + e.AttributeName + "' in tag '" + e.TagName + "'"
+	case InvAttributeAlias:
+		text = "attribute '" + e.AttributeName + "' in tag '" + e.TagName + "' is an alias"
+	case InvEndTagForVoid:
+		text = "void element '" + e.TagName + "' has an end tag"
+	case InvAttrQuoteStyle:
+		text = "attribute '" + e.AttributeName + "' in tag '" + e.TagName + "' is not double quoted"
+	case InvBadAutocomplete:
+		text = "invalid autocomplete value in tag '" + e.TagName + "'"
+	case InvMultipleMain:
+		text = "more than one '" + e.TagName + "' element"
+	case InvPositiveTabindex:
+		text = "positive tabindex in tag '" + e.TagName + "'"
+	case InvBadDataAttrValue:
+		text = "invalid value for attribute '" + e.AttributeName + "' in tag '" + e.TagName + "'"
+	case InvBadHttpEquiv:
+		text = "invalid http-equiv in tag '" + e.TagName + "'"
+	case InvBadSourceContext:
+		text = "attribute '" + e.AttributeName + "' of 'source' does not fit its parent"
+	case InvNonLabelableTarget:
+		text = "label in tag '" + e.TagName + "' does not point to a labelable element"
+	case InvLegacyDoctype:
+		text = "legacy doctype"
+	case InvDoctypeForm:
+		text = "doctype is not written as required"
+	case InvContentModel:
+		text = "tag '" + e.TagName + "' is not allowed here"
+	case InvMalformedAttrValue:
+		text = "malformed value for attribute '" + e.AttributeName + "' in tag '" + e.TagName + "'"
+	case InvUnknownAria:
+		text = "unknown ARIA attribute '" + e.AttributeName + "' in tag '" + e.TagName + "'"
+	case InvAmbiguousLandmark:
+		text = "'" + e.TagName + "' landmark has no accessible name"
+	case InvMaxDepthExceeded:
+		text = "maximum nesting depth exceeded, validation stopped"
+	case InvBadFormConfig:
+		text = "attribute '" + e.AttributeName + "' does not fit the other attributes of '" + e.TagName + "'"
+	case InvBadTrack:
+		text = "attribute '" + e.AttributeName + "' of 'track' is missing or invalid"
+	case InvMissingNonce:
+		text = "inline script has no nonce"
+	case InvMissingTitle:
+		text = "document has no title"
+	case InvMultipleTitles:
+		text = "more than one title"
+	case InvEmptyTitle:
+		text = "title is empty"
+	case InvBadLoading:
+		text = "invalid loading value in tag '" + e.TagName + "'"
+	case InvMissingLoading:
+		text = "large '" + e.TagName + "' has no loading attribute"
+	case InvExcessiveNesting:
+		text = "tag '" + e.TagName + "' is nested too deeply in itself"
+	case InvMultipleChecked:
+		text = "more than one checked radio button in a group"
+	case InvNestedForm:
+		text = "form inside another form"
+	case InvLargeInlineData:
+		text = "large data URI in attribute '" + e.AttributeName + "' in tag '" + e.TagName + "'"
+	case InvMissingAttribute:
+		text = "required attribute '" + e.AttributeName + "' missing in tag '" + e.TagName + "'"
+	case InvAttributeValue:
+		text = "invalid value for attribute '" + e.AttributeName + "' in tag '" + e.TagName + "'"
+	case InvMissingScope:
+		text = "header cell '" + e.TagName + "' has no scope"
+	case InvBadScope:
+		text = "invalid scope in tag '" + e.TagName + "'"
+	case InvIllegalNesting:
+		text = "tag '" + e.TagName + "' is not allowed in this parent"
+	case InvMissingAttributeValue:
+		text = "attribute '" + e.AttributeName + "' in tag '" + e.TagName + "' has no value"
+	case InvMalformedComment:
+		text = "malformed comment"
+	case InvMissingDoctype:
+		text = "document does not start with a doctype"
+	case InvDocumentStructure:
+		text = "element '" + e.TagName + "' is missing or misplaced"
+	case InvDuplicateId:
+		text = "duplicate " + e.AttributeName + " '" + e.AttributeValue + "' in tag '" + e.TagName + "'"
+	case InvDeprecatedTag:
+		text = "tag '" + e.TagName + "' is deprecated"
+	case InvEventHandler:
+		text = "event handler attribute '" + e.AttributeName + "' in tag '" + e.TagName + "'"
+	case InvUnsafeURL:
+		text = "unsafe URL in attribute '" + e.AttributeName + "' in tag '" + e.TagName + "'"
+	case InvSelfClosingNonVoid:
+		text = "self-closing syntax on non-void tag '" + e.TagName + "'"
+	case InvTooManyAttributes:
+		text = "too many attributes in tag '" + e.TagName + "'"
+	default:
+		text = e.Reason.String() + " in tag '" + e.TagName + "'"
 	}
 
 	pos := ""
@@ -103,10 +475,74 @@ func (e *ValidationError) Error() string {
 		pos = pos + " (L" + line + ", C" + column + ")"
 	}
 
+	if e.Note != "" {
+		pos = pos + ": " + e.Note
+	}
+
 	return text + pos
 }
 
-func (v *Validator) AddValidTags(validTags []*ValidTag) {
+// valueText describes the attribute value, if there is one.
+func (e *ValidationError) valueText() string {
+	if e.AttributeValue == "" {
+		return ""
+	}
+	return " with value '" + e.AttributeValue + "'"
+}
+
+// Fingerprint returns an id that stays the same across runs as long as the
+// reason, tag, attribute and position of the error do. The line and column
+// are used when known, so edits on other lines don't change it. Severity
+// and Note are ignored.
+func (e *ValidationError) Fingerprint() string {
+	pos := "@" + strconv.Itoa(e.Pos.Start)
+	if e.TextPos != nil {
+		pos = "L" + strconv.Itoa(e.TextPos.Line) + ":" +
+			strconv.Itoa(e.TextPos.Column)
+	}
+	h := fnv.New64a()
+	io.WriteString(h, e.Reason.String()+"\x00"+e.TagName+"\x00"+
+		e.AttributeName+"\x00"+pos)
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// AddValidTags adds the tags to the validator. It returns an error and adds
+// nothing if a tag is defined twice, including a second global tag, or if
+// AttrRegEx or a pattern in AttrValueRegEx doesn't compile.
+func (v *Validator) AddValidTags(validTags []*ValidTag) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	patterns := map[string]map[string]*regexp.Regexp{}
+	namePatterns := map[string]*regexp.Regexp{}
+	seen := map[string]bool{}
+	for _, tag := range validTags {
+		if _, ok := v.validTags[tag.Name]; ok || seen[tag.Name] {
+			if tag.Name == "" {
+				return errors.New("second global tag")
+			}
+			return fmt.Errorf("tag '%s' is defined twice", tag.Name)
+		}
+		seen[tag.Name] = true
+		if tag.AttrRegEx != "" {
+			re, err := regexp.Compile(tag.AttrRegEx)
+			if err != nil {
+				return fmt.Errorf("tag '%s': AttrRegEx: %v", tag.Name, err)
+			}
+			namePatterns[tag.Name] = re
+		}
+		for attr, pattern := range tag.AttrValueRegEx {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("tag '%s': value pattern of attribute "+
+					"'%s': %v", tag.Name, attr, err)
+			}
+			if patterns[tag.Name] == nil {
+				patterns[tag.Name] = map[string]*regexp.Regexp{}
+			}
+			patterns[tag.Name][attr] = re
+		}
+	}
+
 	if v.validSelfClosingTags == nil {
 		v.validSelfClosingTags = make(map[string]bool)
 	}
@@ -125,13 +561,30 @@ func (v *Validator) AddValidTags(validTags []*ValidTag) {
 		for _, a := range tag.Attrs {
 			v.validTagMap[tag.Name][a] = true
 		}
-		if tag.Name == "" {
-			_, hasGlobalTag := v.validTags[""]
-			if hasGlobalTag {
-				log.Println("second global tag")
-			}
+		for _, a := range tag.RecommendedAttrs {
+			v.validTagMap[tag.Name][a] = true
+		}
+		for _, a := range tag.RequiredAttrs {
+			v.validTagMap[tag.Name][a] = true
+		}
+		for _, a := range tag.ValueAttrs {
+			v.validTagMap[tag.Name][a] = true
+		}
+		if len(tag.ValueAttrs) > 0 {
+			v.hasValueAttrs = true
+		}
+		if len(tag.AutoClosedBy) > 0 {
+			v.hasAutoClose = true
 		}
 		v.validTags[tag.Name] = tag
+		if v.attrValuePatterns == nil {
+			v.attrValuePatterns = map[string]map[string]*regexp.Regexp{}
+		}
+		v.attrValuePatterns[tag.Name] = patterns[tag.Name]
+		if v.attrNamePatterns == nil {
+			v.attrNamePatterns = map[string]*regexp.Regexp{}
+		}
+		v.attrNamePatterns[tag.Name] = namePatterns[tag.Name]
 
 		for _, groupName := range tag.Groups {
 			group := v.validGroups[groupName]
@@ -140,10 +593,76 @@ func (v *Validator) AddValidTags(validTags []*ValidTag) {
 			}
 		}
 	}
+	return nil
+}
+
+func (v *Validator) AddValidTag(validTag ValidTag) error {
+	return v.AddValidTags([]*ValidTag{&validTag})
+}
+
+// RemoveTag removes a tag added with AddValidTags. Removing a tag that was
+// never added does nothing.
+func (v *Validator) RemoveTag(name string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.validTagMap, name)
+	delete(v.validTags, name)
+	delete(v.validSelfClosingTags, name)
+	delete(v.attrValuePatterns, name)
+	delete(v.attrNamePatterns, name)
+}
+
+// AddGlobalAttrs allows the attributes on every tag. It is preferred over
+// adding a ValidTag with an empty Name.
+func (v *Validator) AddGlobalAttrs(attrs []string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.globalAttrs == nil {
+		v.globalAttrs = map[string]bool{}
+	}
+	for _, attr := range attrs {
+		v.globalAttrs[attr] = true
+	}
+}
+
+// AddGlobalAttrRegEx allows attributes matching pattern, like "^data-", on
+// every tag.
+func (v *Validator) AddGlobalAttrRegEx(pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.globalAttrPatterns = append(v.globalAttrPatterns, re)
+	return nil
 }
 
-func (v *Validator) AddValidTag(validTag ValidTag) {
-	v.AddValidTags([]*ValidTag{&validTag})
+// AddDataAttrs declares data attributes, mapped to a pattern their whole
+// value has to match or "" for any value. Once declared, other data
+// attributes are not valid, even when an AttrStartsWith allows them.
+func (v *Validator) AddDataAttrs(schema map[string]string) error {
+	patterns := map[string]*regexp.Regexp{}
+	for name, pattern := range schema {
+		if pattern == "" {
+			patterns[name] = nil
+			continue
+		}
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return err
+		}
+		patterns[name] = re
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.dataAttrs == nil {
+		v.dataAttrs = map[string]*regexp.Regexp{}
+	}
+	for name, re := range patterns {
+		v.dataAttrs[strings.ToLower(name)] = re
+	}
+	return nil
 }
 
 func (v *Validator) AddGroup(group *TagGroup) {
@@ -151,6 +670,8 @@ func (v *Validator) AddGroup(group *TagGroup) {
 }
 
 func (v *Validator) AddGroups(groups []*TagGroup) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
 	if v.validGroups == nil {
 		v.validGroups = map[string]*TagGroup{}
 	}
@@ -176,16 +697,49 @@ func (tag *ValidTag) HasGroup(groupName string) bool {
 	return false
 }
 
+// DisableReason stops errors with the reason from being reported.
+func (v *Validator) DisableReason(r ErrorReason) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.disabledReasons == nil {
+		v.disabledReasons = map[ErrorReason]bool{}
+	}
+	v.disabledReasons[r] = true
+}
+
+// EnableReason reports errors with a reason disabled by DisableReason
+// again.
+func (v *Validator) EnableReason(r ErrorReason) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.disabledReasons, r)
+}
+
 func (v *Validator) RegisterCallback(f ErrorCallback) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
 	v.errorCallback = f
+	v.contextCallback = nil
 }
 
 func (v *Validator) IsValidTag(tagName string) bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.isValidTag(tagName)
+}
+
+func (v *Validator) isValidTag(tagName string) bool {
 	_, ok := v.validTagMap[tagName]
 	return ok
 }
 
 func (v *Validator) IsValidSelfClosingTag(tagName string) bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.isValidSelfClosingTag(tagName)
+}
+
+func (v *Validator) isValidSelfClosingTag(tagName string) bool {
 	_, ok := v.validSelfClosingTags[tagName]
 	if !ok {
 		return false
@@ -207,9 +761,28 @@ func (v *Validator) LoadTagsFromFile(path string) error {
 	}
 
 	v.AddGroups(tagFile.Groups)
-	v.AddValidTags(tagFile.Tags)
+	return v.AddValidTags(tagFile.Tags)
+}
 
-	return nil
+// LoadTagsFromJSON reads a JSON array of tag definitions, using the
+// ValidTag field names in any case, and adds them with AddValidTags.
+// Unknown fields are an error.
+func (v *Validator) LoadTagsFromJSON(r io.Reader) error {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	tags := []*ValidTag{}
+	if err := dec.Decode(&tags); err != nil {
+		return fmt.Errorf("invalid tag definitions: %v", err)
+	}
+	if dec.More() {
+		return errors.New("invalid tag definitions: data after the array")
+	}
+	for i, tag := range tags {
+		if tag == nil {
+			return fmt.Errorf("invalid tag definitions: entry %d is null", i)
+		}
+	}
+	return v.AddValidTags(tags)
 }
 
 /*func (v *Validator) WriteTagsToFile(path string) error {
@@ -222,7 +795,28 @@ func (v *Validator) LoadTagsFromFile(path string) error {
 	return nil
 }*/
 
+// IsValidAttribute reports whether attrName is allowed on tagName, either
+// directly or through its canonical name in AttrAliases.
 func (v *Validator) IsValidAttribute(tagName string, attrName string) bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.isValidAttribute(tagName, attrName)
+}
+
+func (v *Validator) isValidAttribute(tagName string, attrName string) bool {
+	if v.isKnownAttribute(tagName, attrName) {
+		return true
+	}
+	canonical, ok := v.AttrAliases[attrName]
+	return ok && v.isKnownAttribute(tagName, canonical)
+}
+
+func (v *Validator) isKnownAttribute(tagName string, attrName string) bool {
+	if v.dataAttrs != nil && strings.HasPrefix(attrName, "data-") {
+		_, declared := v.dataAttrs[attrName]
+		return declared
+	}
+
 	attrs, hasTag := v.validTagMap[tagName]
 	gAttrs, hasGlobals := v.validTagMap[""] //check global attributes
 
@@ -239,6 +833,15 @@ func (v *Validator) IsValidAttribute(tagName string, attrName string) bool {
 		}
 	}
 
+	if v.globalAttrs[attrName] {
+		return true
+	}
+	for _, re := range v.globalAttrPatterns {
+		if re.MatchString(attrName) {
+			return true
+		}
+	}
+
 	if hasGlobals {
 		_, hasGlobalAttr := gAttrs[attrName]
 		if hasGlobalAttr {
@@ -251,79 +854,437 @@ func (v *Validator) IsValidAttribute(tagName string, attrName string) bool {
 	return false
 }
 
+// attrValuePattern returns the AttrValueRegEx pattern for an attribute of
+// the tag, falling back to the global tag, or nil if there is none.
+func (v *Validator) attrValuePattern(tagName string,
+	attrName string) *regexp.Regexp {
+	if re, ok := v.attrValuePatterns[tagName][attrName]; ok {
+		return re
+	}
+	return v.attrValuePatterns[""][attrName]
+}
+
 func (v *Validator) testAttribute(tagName string, attrName string) bool {
 	tag := v.validTags[tagName]
 	if tag.AttrStartsWith != "" {
 		return strings.HasPrefix(attrName, tag.AttrStartsWith)
 	}
-	if tag.AttrRegEx != "" {
-		matches, err := regexp.MatchString(tag.AttrRegEx, attrName)
-		if err == nil && matches {
-			return true
-		}
+	if re := v.attrNamePatterns[tagName]; re != nil {
+		return re.MatchString(attrName)
 	}
 	return false
 }
 
+// checkComment reports a comment that is never closed, closed abruptly
+// like <!--> or that contains "--". Bogus comments like <?xml ?> are left
+// alone.
+func (v *Validator) checkComment(s *validation, raw []byte, pos Span) {
+	text := string(raw)
+	if !strings.HasPrefix(text, "<!--") {
+		return
+	}
+	if len(text) < len("<!---->") || !strings.HasSuffix(text, "-->") {
+		cError := v.report(s, "", "", text, pos, InvMalformedComment)
+		if cError != nil {
+			cError.Note = "the comment is not closed"
+		}
+		return
+	}
+	if strings.Contains(text[4:len(text)-3], "--") {
+		cError := v.report(s, "", "", text, pos, InvMalformedComment)
+		if cError != nil {
+			cError.Note = "comments must not contain '--'"
+		}
+	}
+}
+
+// isAllowedComment reports whether a comment body passes AllowedComment and
+// DisallowedComment. An allowed match always wins; with only AllowedComment
+// set, every other comment is rejected.
+func (v *Validator) isAllowedComment(text string) bool {
+	if v.AllowedComment != nil && v.AllowedComment.MatchString(text) {
+		return true
+	}
+	if v.DisallowedComment != nil {
+		return !v.DisallowedComment.MatchString(text)
+	}
+	return v.AllowedComment == nil
+}
+
 func (v *Validator) ValidateHtmlString(str string) []*ValidationError {
 	buffer := strings.NewReader(str)
 	errors := v.ValidateHtml(buffer)
-	//updateLineColumns(str, errors)
 	return errors
 }
 
+// IsValid reports whether str has no errors of SeverityError. It stops at
+// the first one and returns it. Warnings are ignored.
+func (v *Validator) IsValid(str string) (bool, *ValidationError) {
+	var first *ValidationError
+	v.validate(strings.NewReader(str), func(err *ValidationError) bool {
+		if err.Severity == SeverityError {
+			first = err
+		}
+		return first == nil
+	})
+	return first == nil, first
+}
+
+// ValidateBytes validates b without copying it into a string.
+func (v *Validator) ValidateBytes(b []byte) []*ValidationError {
+	return v.ValidateHtml(bytes.NewReader(b))
+}
+
 func UpdateErrorLines(str string, errors []*ValidationError) {
 	updateLineColumns(str, errors)
 }
 
 func updateLineColumns(str string, errors []*ValidationError) {
-	lines := strings.Split(str, "\n")
+	lineStarts := []int{0}
+	continuations := []int{}
+	for i := 0; i < len(str); i++ {
+		if str[i] == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		} else if isContinuation(str[i]) {
+			continuations = append(continuations, i)
+		}
+	}
 	for _, k := range errors {
-		charCount := 0
-		for i, l := range lines {
-			lineLen := len(l) + 1
-			if k.Pos.Start < (charCount + lineLen) {
-				tPos := TextPos{i + 1, k.Pos.Start - charCount + 1}
-				k.TextPos = &tPos
-				break
-			}
-			charCount += lineLen
+		if k.Pos.Start <= len(str) {
+			k.TextPos = textPos(lineStarts, continuations, k.Pos.Start)
 		}
 	}
 }
 
-func (v *Validator) checkErrorCallback(tagName string, attr string,
-	value string, span Span, reason ErrorReason) *ValidationError {
+// isContinuation reports whether c is a UTF-8 continuation byte, one that
+// doesn't start a rune.
+func isContinuation(c byte) bool {
+	return c&0xC0 == 0x80
+}
+
+// textPos returns the line and column of offset, given the offsets at
+// which each line starts and of the UTF-8 continuation bytes. Columns
+// count runes.
+func textPos(lineStarts []int, continuations []int, offset int) *TextPos {
+	line := sort.SearchInts(lineStarts, offset+1) - 1
+	column := offset - lineStarts[line] + 1
+	column -= sort.SearchInts(continuations, offset) -
+		sort.SearchInts(continuations, lineStarts[line])
+	return &TextPos{line + 1, column}
+}
+
+// trackLines moves the line and column to the start of the current token
+// and keeps a copy of it, so errors get a TextPos without a second pass
+// over the input. Positions in earlier tokens have to be saved when they
+// are seen.
+func (s *validation) trackLines(d *html.Tokenizer) {
+	start, _ := d.GetTokenPosition()
+	s.line, s.column = s.lineColumn(start)
+	s.tokenStart = start
+	s.tokenRaw = append(s.tokenRaw[:0], d.Raw()...)
+}
+
+// lineColumn returns the line and column of offset, counting runes from
+// the start of the current token. offset must not be before it.
+func (s *validation) lineColumn(offset int) (int, int) {
+	line, column := s.line, s.column
+	n := offset - s.tokenStart
+	if n > len(s.tokenRaw) {
+		n = len(s.tokenRaw)
+	}
+	for _, c := range s.tokenRaw[:n] {
+		if c == '\n' {
+			line++
+			column = 1
+		} else if !isContinuation(c) {
+			column++
+		}
+	}
+	return line, column
+}
+
+// textPos returns the line and column of offset, or nil if it is in an
+// earlier token. The start of the document is always known.
+func (s *validation) textPos(offset int) *TextPos {
+	if offset == 0 {
+		return &TextPos{1, 1}
+	}
+	if offset < s.tokenStart {
+		return nil
+	}
+	line, column := s.lineColumn(offset)
+	return &TextPos{line, column}
+}
+
+func (v *Validator) checkErrorCallback(s *validation, tagName string,
+	attr string, value string, span Span, textPos *TextPos,
+	reason ErrorReason) *ValidationError {
+	if v.disabledReasons[reason] {
+		return nil
+	}
+	if v.contextCallback != nil {
+		ctx := &Context{s: s, pos: span, textPos: textPos}
+		defer func() { ctx.s = nil }()
+		return v.contextCallback(ctx, tagName, attr, value, reason)
+	}
 	if v.errorCallback != nil {
 		return v.errorCallback(tagName, attr, value, reason)
 	}
-	return &ValidationError{tagName, attr, reason, span, nil}
+	severity := SeverityError
+	if configured, ok := v.Severities[reason]; ok {
+		severity = configured
+	} else if warningReasons[reason] ||
+		reason == InvTag && v.AllowUnknownTags {
+		severity = SeverityWarning
+	}
+	return &ValidationError{TagName: tagName, AttributeName: attr,
+		AttributeValue: value, Reason: reason, Pos: span, Severity: severity}
+}
+
+// element is an open tag on the parents stack. span covers the whole
+// opening tag.
+type element struct {
+	name    string
+	span    Span
+	textPos *TextPos
+	depth   int // open non-void elements up to and including this one
+	node    *Node
+	ns      string // namespace root the children of this element are in
+}
+
+// validation holds the state of a single run over a document.
+type validation struct {
+	d       *html.Tokenizer
+	parents []element
+	emit    func(*ValidationError) bool
+	pending *ValidationError
+	stop    bool
+	emitted int
+
+	profile bool
+	mark    time.Time
+	timings Timings
+
+	headChildren int
+
+	skipTag     string
+	skipNesting int
+
+	mainCount int
+
+	started bool // a token other than white space or a comment was seen
+
+	documentElements map[string]bool
+
+	titleCount   int
+	titleText    string
+	titlePos     Span
+	titleTextPos *TextPos
+	landmarks    map[string][]landmark
+
+	ids map[string]idTarget
+
+	uniqueValues map[string]*TextPos
+	labelFors    []labelRef
+	label        *openLabel
+
+	checkedRadios map[string]bool
+
+	ctx    context.Context
+	tokens int
+
+	collectText bool
+	texts       []TextRun
+	stats       *Stats
+
+	// line and column are where the current token starts. tokenRaw is a
+	// copy of the token, as Token rewrites the tokenizer's buffer.
+	tokenStart int
+	tokenRaw   []byte
+	line       int
+	column     int
+}
+
+func (v *Validator) newValidation(r io.Reader,
+	emit func(*ValidationError) bool) *validation {
+	s := &validation{
+		d:       html.NewTokenizer(r),
+		parents: []element{},
+		emit:    emit,
+		line:    1,
+		column:  1,
+		profile: v.Profile,
+	}
+	if s.profile {
+		s.mark = time.Now()
+	}
+	return s
 }
 
+// report runs the error callback and returns the resulting error so
+// callers can add details to it. The error is delivered when the next one
+// is reported or the current token is done.
+func (v *Validator) report(s *validation, tagName string, attr string,
+	value string, span Span, reason ErrorReason) *ValidationError {
+	return v.reportAt(s, tagName, attr, value, span, s.textPos(span.Start),
+		reason)
+}
+
+// reportAt is report for a span in an earlier token, with the line and
+// column saved when it was seen.
+func (v *Validator) reportAt(s *validation, tagName string, attr string,
+	value string, span Span, textPos *TextPos,
+	reason ErrorReason) *ValidationError {
+	v.flush(s)
+	if s.stop {
+		return nil
+	}
+	s.pending = v.checkErrorCallback(s, tagName, attr, value, span, textPos,
+		reason)
+	if s.pending != nil && s.pending.TextPos == nil {
+		s.pending.TextPos = textPos
+	}
+	return s.pending
+}
+
+// flush delivers the error returned by the last report, if it wasn't
+// delivered yet.
+func (v *Validator) flush(s *validation) {
+	if cError := s.pending; cError != nil {
+		s.pending = nil
+		v.deliver(s, cError)
+	}
+}
+
+// stopped flushes the last reported error and reports whether s stopped.
+func (v *Validator) stopped(s *validation) bool {
+	v.flush(s)
+	return s.stop
+}
+
+// deliver passes cError to s.emit and stops the validation when emit asks
+// for it or one of the validator's limits is reached.
+func (v *Validator) deliver(s *validation, cError *ValidationError) {
+	if cError.TextPos == nil {
+		cError.TextPos = s.textPos(cError.Pos.Start)
+	}
+	if v.TreatWarningsAsErrors {
+		cError.Severity = SeverityError
+	}
+	s.emitted++
+	if !s.emit(cError) ||
+		v.StopAfterFirstError && cError.Severity == SeverityError ||
+		v.MaxErrors > 0 && s.emitted >= v.MaxErrors {
+		s.stop = true
+	}
+}
+
+// ValidateHtml returns the errors found in r. A tag can produce several
+// errors, one for every check it fails. StopAfterFirstError ends the
+// validation at the first error of SeverityError, MaxErrors after that
+// many errors of any severity.
 func (v *Validator) ValidateHtml(r io.Reader) []*ValidationError {
-	d := html.NewTokenizer(r)
-	parents := []string{}
-	var err *ValidationError
 	errors := []*ValidationError{}
-	for {
-		parents, err = v.checkToken(d, parents)
+	v.validate(r, func(err *ValidationError) bool {
+		errors = append(errors, err)
+		return true
+	})
+	return errors
+}
 
-		if err != nil {
-			if err.Reason == InvEOF {
-				break
-			}
-			errors = append(errors, err)
-			if v.StopAfterFirstError {
-				return errors
-			}
-		}
-	}
+// ValidateHtmlStream calls fn for every error as soon as it is found,
+// without collecting them. Validation stops when fn returns false. It
+// returns the first read error of r, if any.
+func (v *Validator) ValidateHtmlStream(r io.Reader,
+	fn func(*ValidationError) bool) error {
+	return v.validate(r, fn)
+}
 
-	err = v.checkParents(d, parents)
-	if err != nil {
+const ctxCheckInterval = 64
+
+// ValidateHtmlContext validates r like ValidateHtml but stops when ctx is
+// done. It then returns the errors found so far and ctx.Err().
+func (v *Validator) ValidateHtmlContext(ctx context.Context,
+	r io.Reader) ([]*ValidationError, error) {
+	errors := []*ValidationError{}
+	s := v.newValidation(r, func(err *ValidationError) bool {
 		errors = append(errors, err)
+		return true
+	})
+	s.ctx = ctx
+	err := v.run(s)
+	return errors, err
+}
+
+// validate passes every error to emit as soon as it is found and stops when
+// emit returns false. It returns the first read error of r, if any.
+func (v *Validator) validate(r io.Reader,
+	emit func(*ValidationError) bool) error {
+	return v.run(v.newValidation(r, emit))
+}
+
+// run validates the document of s until the end of input or until s stops.
+func (v *Validator) run(s *validation) error {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	for !v.stopped(s) && !s.cancelled() && v.checkToken(s) {
+		s.lap(&s.timings.Rules)
 	}
-	return errors
+
+	if !v.stopped(s) {
+		v.checkParents(s)
+	}
+	if !v.stopped(s) && v.closeRules != nil {
+		v.closeElements(s, s.parents)
+	}
+	if !v.stopped(s) && v.requiresDoctype() && !s.started {
+		v.report(s, "", "", "", Span{}, InvMissingDoctype)
+	}
+	if !v.stopped(s) && v.Mode == ModeDocument {
+		v.checkDocumentElements(s)
+	}
+	if !v.stopped(s) && v.CheckForms {
+		v.checkLabelTargets(s)
+	}
+	if !v.stopped(s) && v.CheckTitle && v.RequireTitle && s.titleCount == 0 {
+		v.report(s, "title", "", "", Span{}, InvMissingTitle)
+	}
+	if !v.stopped(s) && v.CheckA11y {
+		v.checkLandmarks(s)
+	}
+	v.flush(s)
+	if s.profile {
+		s.lap(&s.timings.Rules)
+		v.addTimings(&s.timings)
+	}
+	if s.ctx != nil && s.ctx.Err() != nil {
+		return s.ctx.Err()
+	}
+	if err := s.d.Err(); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// cancelled checks the context of s every ctxCheckInterval tokens and
+// stops s once it is done.
+func (s *validation) cancelled() bool {
+	if s.ctx == nil {
+		return false
+	}
+	if s.tokens%ctxCheckInterval == 0 && s.ctx.Err() != nil {
+		s.stop = true
+	}
+	s.tokens++
+	return s.stop
+}
+
+// ValidateHtmlEncoding decodes r from enc to UTF-8 before validating it.
+// Positions in the returned errors refer to the decoded UTF-8 text.
+func (v *Validator) ValidateHtmlEncoding(r io.Reader,
+	enc encoding.Encoding) []*ValidationError {
+	return v.ValidateHtml(enc.NewDecoder().Reader(r))
 }
 
 func indexOf(arr []string, val string) int {
@@ -335,34 +1296,94 @@ func indexOf(arr []string, val string) int {
 	return -1
 }
 
-func (v *Validator) correctError(err *ValidationError, parents []string,
-	tokenType html.TokenType, token html.Token) []string {
-	if err.Reason == InvClosedBeforeOpened && tokenType == html.EndTagToken {
-		index := indexOf(parents, token.Data)
-		if index > -1 {
-			parents = parents[0:index]
+func hasAttr(token html.Token, key string) bool {
+	for _, attr := range token.Attr {
+		if attr.Key == key {
+			return true
 		}
 	}
-	fmt.Println("correct", parents, tokenType, token.Data)
-	return parents
+	return false
 }
 
-func (v *Validator) checkParents(d *html.Tokenizer, parents []string) *ValidationError {
-	for _, tagName := range parents {
-		if v.IsValidSelfClosingTag(tagName) {
+// javaScriptTypes are the script types, without parameters, that run as
+// JavaScript. Other types are data blocks.
+var javaScriptTypes = setOf("", "module", "application/ecmascript",
+	"application/javascript", "application/x-ecmascript",
+	"application/x-javascript", "text/ecmascript", "text/javascript",
+	"text/javascript1.0", "text/javascript1.1", "text/javascript1.2",
+	"text/javascript1.3", "text/javascript1.4", "text/javascript1.5",
+	"text/jscript", "text/livescript", "text/x-ecmascript",
+	"text/x-javascript")
+
+// isJavaScript reports whether the script token runs as JavaScript.
+func isJavaScript(token html.Token) bool {
+	scriptType, _ := attrValue(token, "type")
+	if i := strings.IndexByte(scriptType, ';'); i >= 0 {
+		scriptType = scriptType[:i]
+	}
+	return javaScriptTypes[strings.ToLower(strings.TrimSpace(scriptType))]
+}
+
+func (v *Validator) checkParents(s *validation) {
+	for _, parent := range s.parents {
+		if v.isValidSelfClosingTag(parent.name) ||
+			v.hasOptionalEndTag(parent.name) {
 			continue
 		}
 
-		pos := getPosition(d)
-		cError := v.checkErrorCallback(tagName, "", "", pos, InvNotProperlyClosed)
-		if cError != nil {
-			return cError
+		if v.reportAt(s, parent.name, "", "", parent.span, parent.textPos,
+			InvNotProperlyClosed) != nil {
+			return
 		}
 	}
-	return nil
 }
 
-func popLast(list []string) []string {
+func (s *validation) indexOf(tagName string) int {
+	for i, parent := range s.parents {
+		if parent.name == tagName {
+			return i
+		}
+	}
+	return -1
+}
+
+// lastIndexOf returns the index of the innermost open element named
+// tagName, or -1.
+func (s *validation) lastIndexOf(tagName string) int {
+	for i := len(s.parents) - 1; i >= 0; i-- {
+		if s.parents[i].name == tagName {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *validation) countOf(tagName string) int {
+	count := 0
+	for _, parent := range s.parents {
+		if parent.name == tagName {
+			count++
+		}
+	}
+	return count
+}
+
+// parent returns the name of the innermost open tag.
+func (s *validation) parent() string {
+	if len(s.parents) == 0 {
+		return ""
+	}
+	return s.parents[len(s.parents)-1].name
+}
+
+func (s *validation) depth() int {
+	if len(s.parents) == 0 {
+		return 0
+	}
+	return s.parents[len(s.parents)-1].depth
+}
+
+func popLast(list []element) []element {
 	if len(list) == 0 {
 		return list
 	}
@@ -374,85 +1395,566 @@ func getPosition(d *html.Tokenizer) Span {
 	return Span{posStart, posEnd}
 }
 
-func (v *Validator) checkToken(d *html.Tokenizer,
-	parents []string) ([]string, *ValidationError) {
+func getTokenPosition(d *html.Tokenizer) Span {
+	posStart, posEnd := d.GetTokenPosition()
+	return Span{posStart, posEnd}
+}
 
+func (v *Validator) checkToken(s *validation) bool {
+	d := s.d
 	tokenType := d.Next()
+	s.lap(&s.timings.Tokenize)
+	s.trackLines(d)
 
 	if tokenType == html.ErrorToken {
-		return parents, &ValidationError{"", "", InvEOF, Span{0, 0}, nil}
+		if v.CheckMalformedAttrs && d.Err() == io.EOF {
+			v.checkTruncatedTag(s, d.Raw(), getTokenPosition(d))
+		}
+		return false
 	}
 
 	pos := getPosition(d)
+	s.lap(&s.timings.Positions)
+	var raw []byte
+	if v.needsRawAttrs() && (tokenType == html.StartTagToken ||
+		tokenType == html.SelfClosingTagToken) {
+		// Token unescapes attribute values in place, so keep a copy.
+		raw = append(raw, d.Raw()...)
+	} else if v.CheckComments && tokenType == html.CommentToken {
+		raw = append(raw, d.Raw()...)
+	}
+	var rawName string
+	if s.namespace() != "" && (tokenType == html.StartTagToken ||
+		tokenType == html.SelfClosingTagToken ||
+		tokenType == html.EndTagToken) {
+		// Token lower-cases the tag name in place.
+		rawName = rawTagName(d.Raw())
+	}
 	token := d.Token()
+	s.lap(&s.timings.Tokenize)
 	//pos := getPosition(d)
 
+	if s.skipTag != "" && s.skipping(token) {
+		return true
+	}
+
+	if tokenType == html.TextToken && v.CheckTitle && s.parent() == "title" {
+		s.titleText += token.Data
+	}
+
+	if tokenType == html.TextToken && v.closeRules != nil {
+		v.addTextNode(s, token.Data, pos)
+	}
+
+	if s.stats != nil && (tokenType == html.StartTagToken ||
+		tokenType == html.SelfClosingTagToken) {
+		s.countToken(token)
+	}
+
+	if tokenType == html.TextToken && s.collectText {
+		v.collectText(s, token.Data, pos)
+	}
+
+	if v.requiresDoctype() && !s.started {
+		v.checkFirstToken(s, token, pos)
+	}
+
+	if tokenType == html.DoctypeToken && v.CheckDoctype {
+		v.checkDoctype(s, string(d.Raw()), token.Data, pos)
+	}
+
+	if v.CheckComments && tokenType == html.CommentToken {
+		v.checkComment(s, raw, getTokenPosition(d))
+	}
+	if tokenType == html.CommentToken && !v.isAllowedComment(token.Data) {
+		v.report(s, "", "", token.Data, pos, InvDisallowedComment)
+	}
+
 	if tokenType == html.EndTagToken ||
 		tokenType == html.StartTagToken ||
 		tokenType == html.SelfClosingTagToken {
 
 		tagName := token.Data
+		if rawName != "" {
+			tagName = s.qualify(token, rawName)
+		}
 
-		if !v.IsValidTag(tagName) {
-			cError := v.checkErrorCallback(tagName, "", "", pos, InvTag)
-			if cError != nil {
-				return parents, cError
+		passUnknown := v.AllowUnknownTags && !v.UnknownTagsAreVoid &&
+			!v.isValidTag(tagName)
+		if passUnknown {
+			if token.Type != html.EndTagToken {
+				v.report(s, tagName, "", "", pos, InvTag)
+			}
+		} else if !v.isValidTag(tagName) {
+			if v.report(s, tagName, "", "", pos, InvTag) != nil ||
+				v.UnknownTagsAreVoid {
+				return true
 			}
 		}
 
+		pushed := false
 		if token.Type == html.StartTagToken ||
 			token.Type == html.SelfClosingTagToken {
-			parents = append(parents, tagName)
+			if v.CheckCharsetFirst {
+				v.checkCharsetFirst(s, token, pos)
+			}
+			if v.hasAutoClose {
+				v.autoClose(s, tagName)
+			}
+			if v.Mode == ModeDocument {
+				v.checkStructure(s, tagName, pos)
+			}
+			if v.CheckSelfClosingSyntax &&
+				token.Type == html.SelfClosingTagToken &&
+				!v.isValidSelfClosingTag(tagName) {
+				cError := v.report(s, tagName, "", "", pos,
+					InvSelfClosingNonVoid)
+				if cError != nil {
+					cError.Note = "the '/' is ignored and the element stays open"
+				}
+			}
+
+			depth := s.depth()
+			if !v.isValidSelfClosingTag(tagName) {
+				depth++
+			}
+			if v.MaxDepth > 0 && depth == v.MaxDepth+1 &&
+				!v.isValidSelfClosingTag(tagName) {
+				v.report(s, tagName, "", "", pos, InvMaxDepthExceeded)
+				if v.stopped(s) {
+					return false
+				}
+			}
+			if v.HardMaxDepth > 0 && depth > v.HardMaxDepth {
+				v.report(s, tagName, "", "", pos, InvMaxDepthExceeded)
+				s.stop = true
+				return false
+			}
+			var node *Node
+			if v.closeRules != nil {
+				node = v.openNode(s, token, pos)
+			}
+			ns := s.namespace()
+			if ns == "" && v.namespaces[tagName] {
+				ns = tagName
+			}
+			// Self-closing syntax closes foreign elements.
+			pushed = token.Type != html.SelfClosingTagToken || ns == ""
+			if pushed {
+				span := getTokenPosition(d)
+				s.parents = append(s.parents, element{name: tagName,
+					span: span, textPos: s.textPos(span.Start),
+					depth: depth, node: node, ns: ns})
+			}
+			if token.Type == html.StartTagToken &&
+				indexOf(v.SkipInside, tagName) > -1 {
+				s.skipTag = tagName
+			}
+
+			tag, ok := v.validTags[tagName]
+			if ok && tag.MaxSelfNesting > 0 &&
+				s.countOf(tagName) > tag.MaxSelfNesting {
+				v.report(s, tagName, "", "", pos, InvExcessiveSelfNesting)
+			} else if max, ok := v.MaxChainDepth[tagName]; ok &&
+				s.countOf(tagName) > max {
+				cError := v.report(s, tagName, "", "", pos,
+					InvExcessiveNesting)
+				if cError != nil {
+					cError.Note = "more than " + strconv.Itoa(max) +
+						" nested '" + tagName + "' elements"
+				}
+			}
+			if ok && tag.Deprecated {
+				v.report(s, tagName, "", "", pos, InvDeprecatedTag)
+			}
+			if ok && len(tag.AllowedParents) > 0 {
+				v.checkAllowedParents(s, tag, pos, pushed)
+			}
+			if ok {
+				for _, attr := range tag.RequiredAttrs {
+					if !hasAttr(token, attr) {
+						v.report(s, tagName, attr, "", pos,
+							InvMissingAttribute)
+					}
+				}
+				if len(tag.ValueAttrs) > 0 {
+					v.checkAttrValues(s, tag, token, raw, pos)
+				}
+				for _, attr := range tag.RecommendedAttrs {
+					if !hasAttr(token, attr) {
+						v.report(s, tagName, attr, "", pos,
+							InvMissingRecommendedAttr)
+					}
+				}
+			}
 		}
 
 		attrs := map[string]bool{}
 
-		for _, attr := range token.Attr {
-			if !v.IsValidAttribute(tagName, attr.Key) {
-				cError := v.checkErrorCallback(tagName, attr.Key,
-					attr.Val, pos, InvAttribute)
-				if cError != nil {
-					return parents, cError
+		// Past the limit the attributes are not checked one by one.
+		tooManyAttrs := v.MaxAttrsPerTag > 0 &&
+			len(token.Attr) > v.MaxAttrsPerTag
+		checkedAttrs := token.Attr
+		if tooManyAttrs {
+			cError := v.report(s, tagName, "", "", pos, InvTooManyAttributes)
+			if cError != nil {
+				cError.Note = strconv.Itoa(len(token.Attr)) + " attributes, " +
+					"at most " + strconv.Itoa(v.MaxAttrsPerTag) + " allowed"
+			}
+			checkedAttrs = nil
+		}
+		for _, attr := range checkedAttrs {
+			key := attr.Key
+			if v.BlockEventHandlers && isEventHandler(key) {
+				v.report(s, tagName, key, attr.Val, pos, InvEventHandler)
+			}
+			if v.CheckURLs {
+				if scheme, unsafe := v.isUnsafeURL(key, attr.Val); unsafe {
+					cError := v.report(s, tagName, key, attr.Val, pos,
+						InvUnsafeURL)
+					if cError != nil {
+						cError.Note = "the scheme '" + scheme + "' is not allowed"
+					}
+				}
+			}
+			if !passUnknown && !v.isValidAttribute(tagName, attr.Key) {
+				cError := v.report(s, tagName, attr.Key, attr.Val, pos,
+					InvAttribute)
+				if cError != nil && v.SuggestAttributes {
+					if name := v.suggestAttribute(tagName, attr.Key); name != "" {
+						cError.Note = "did you mean '" + name + "'?"
+					}
 				}
+			} else if canonical, ok := v.AttrAliases[attr.Key]; ok &&
+				!v.isKnownAttribute(tagName, attr.Key) {
+				key = canonical
+				if v.WarnOnAttrAlias {
+					cError := v.report(s, tagName, attr.Key, attr.Val, pos,
+						InvAttributeAlias)
+					if cError != nil {
+						cError.Note = "use '" + canonical + "' instead"
+					}
+				}
+			}
+			if re := v.attrValuePattern(tagName, key); re != nil &&
+				!re.MatchString(attr.Val) {
+				v.report(s, tagName, attr.Key, attr.Val, pos,
+					InvAttributeValue)
+			}
+			if v.dataAttrs != nil && strings.HasPrefix(key, "data-") {
+				v.checkDataAttrValue(s, tagName, key, attr.Val, pos)
 			}
-			_, ok := attrs[attr.Key]
+			_, ok := attrs[key]
 			if !ok {
-				attrs[attr.Key] = true
+				attrs[key] = true
 			} else {
-				cError := v.checkErrorCallback(tagName, attr.Key,
-					attr.Val, pos, InvDuplicatedAttribute)
-				if cError != nil {
-					return parents, cError
-				}
+				v.report(s, tagName, attr.Key, attr.Val, pos,
+					InvDuplicatedAttribute)
 			}
 		}
 
+		if v.CheckUniqueIds && token.Type != html.EndTagToken {
+			v.checkUniqueId(s, token, pos)
+		}
+
+		if v.CheckButtonType && tagName == "button" &&
+			token.Type != html.EndTagToken && !hasAttr(token, "type") {
+			cError := v.report(s, tagName, "type", "", pos,
+				InvImplicitButtonType)
+			if cError != nil {
+				cError.Note = "buttons default to type=\"submit\", " +
+					"set type=\"button\" or type=\"submit\" explicitly"
+			}
+		}
+
+		if v.RequireScriptNonce && tagName == "script" &&
+			token.Type != html.EndTagToken && !hasAttr(token, "src") &&
+			!hasAttr(token, "nonce") && isJavaScript(token) {
+			cError := v.report(s, tagName, "nonce", "", pos, InvMissingNonce)
+			if cError != nil {
+				cError.Note = "a CSP with nonces blocks inline scripts " +
+					"without one"
+			}
+		}
+
+		if v.CheckForms {
+			v.checkForm(s, token, pos)
+		}
+
+		if v.CheckA11y && token.Type != html.EndTagToken {
+			v.checkA11y(s, token, pos)
+		}
+
+		if v.CheckContentCategories && token.Type != html.EndTagToken {
+			v.checkContentCategories(s, token, tagName, pos, pushed)
+		}
+
+		if (v.CheckLoading || v.LazyLoadMinArea > 0) &&
+			(tagName == "img" || tagName == "iframe") &&
+			token.Type != html.EndTagToken {
+			v.checkLoading(s, token, pos)
+		}
+
+		if v.MaxInlineDataSize > 0 && token.Type != html.EndTagToken {
+			v.checkInlineData(s, token, pos)
+		}
+
+		if v.CheckTracks && tagName == "track" &&
+			token.Type != html.EndTagToken {
+			v.checkTrack(s, token, pos)
+		}
+
+		if v.CheckSourceContext && tagName == "source" &&
+			token.Type != html.EndTagToken {
+			v.checkSource(s, token, pos)
+		}
+
+		if v.CheckAriaNames && token.Type != html.EndTagToken {
+			v.checkAriaNames(s, token, pos)
+		}
+
+		if v.CheckAutocomplete && token.Type != html.EndTagToken {
+			value, ok := attrValue(token, "autocomplete")
+			if ok && !IsValidAutocomplete(value) {
+				v.report(s, tagName, "autocomplete", value, pos,
+					InvBadAutocomplete)
+			}
+		}
+
+		if v.CheckRel && (tagName == "link" || tagName == "a") {
+			v.checkRel(s, token, pos)
+		}
+
+		if v.CheckViewport && tagName == "meta" {
+			v.checkViewport(s, token, pos)
+		}
+
+		if v.CheckTitle && tagName == "title" {
+			v.checkTitle(s, token, pos)
+		}
+
+		if v.CheckHttpEquiv && tagName == "meta" {
+			v.checkHttpEquiv(s, token, pos)
+		}
+
+		if v.CheckDatetime && (tagName == "time" || tagName == "ins" ||
+			tagName == "del") {
+			value, ok := attrValue(token, "datetime")
+			if ok && !IsValidDatetime(strings.TrimSpace(value)) {
+				v.report(s, tagName, "datetime", value, pos, InvBadDatetime)
+			}
+		}
+
+		if raw != nil && !tooManyAttrs {
+			v.checkRawAttrs(s, tagName, raw, pos)
+		}
+
 		if token.Type == html.EndTagToken {
-			if len(parents) > 0 && parents[len(parents)-1] == tagName {
-				parents = popLast(parents)
-			} else if len(parents) == 0 ||
-				parents[len(parents)-1] != tagName {
-				index := indexOf(parents, tagName)
+			if v.CheckVoidEndTags && v.isValidSelfClosingTag(tagName) {
+				v.report(s, tagName, "", "", pos, InvEndTagForVoid)
+			}
+
+			parents := s.parents
+			if len(parents) > 0 && s.parent() == tagName {
+				s.parents = popLast(parents)
+				v.closeElements(s, parents[len(parents)-1:])
+			} else if len(parents) == 0 || s.parent() != tagName {
+				index := s.lastIndexOf(tagName)
 				if index > -1 {
-					missingTagName := parents[len(parents)-1]
-					parents = parents[0:index]
-					if !v.IsValidSelfClosingTag(missingTagName) {
-						cError := v.checkErrorCallback(missingTagName,
-							"", "", pos, InvNotProperlyClosed)
-						if cError != nil {
-							return parents, cError
-						}
+					s.parents = parents[0:index]
+					v.closeElements(s, parents[index:])
+					missing, ok := v.unclosedElement(parents[index+1:])
+					if ok {
+						cError := v.reportAt(s, missing.name, "", "",
+							missing.span, missing.textPos,
+							InvNotProperlyClosed)
+						setExpectedTag(cError, missing.name, tagName)
 					}
 				} else {
-					cError := v.checkErrorCallback(tagName,
-						"", "", pos, InvClosedBeforeOpened)
-					if cError != nil {
-						return parents, cError
+					cError := v.report(s, tagName, "", "", pos,
+						InvClosedBeforeOpened)
+					if len(parents) > 0 {
+						setExpectedTag(cError, s.parent(), tagName)
 					}
 				}
 			}
 		}
 	}
 
-	return parents, nil
+	return true
+}
+
+func setExpectedTag(cError *ValidationError, expected string, found string) {
+	if cError == nil || expected == found {
+		return
+	}
+	cError.ExpectedTag = expected
+	cError.Note = "expected </" + expected + "> but found </" + found + ">"
+}
+
+func (v *Validator) checkRel(s *validation, token html.Token, pos Span) {
+	rel, ok := attrValue(token, "rel")
+	if !ok {
+		return
+	}
+	for _, linkType := range strings.Fields(strings.ToLower(rel)) {
+		if !LinkRelations[linkType] {
+			v.report(s, token.Data, "rel", linkType, pos, InvBadRel)
+		}
+	}
+}
+
+func (v *Validator) needsRawAttrs() bool {
+	return v.CheckAttrEscaping || v.RequireDoubleQuotes ||
+		v.CheckMalformedAttrs || v.hasValueAttrs
+}
+
+// isEventHandler reports whether the attribute is an inline event handler
+// like onclick.
+func isEventHandler(name string) bool {
+	return strings.HasPrefix(name, "on")
+}
+
+// checkAttrValues reports ValueAttrs of tag written without a value.
+func (v *Validator) checkAttrValues(s *validation, tag *ValidTag,
+	token html.Token, raw []byte, pos Span) {
+	valueless := map[string]bool{}
+	for _, attr := range parseRawAttrs(raw) {
+		if !attr.HasValue {
+			valueless[strings.ToLower(attr.Key)] = true
+		}
+	}
+	for _, attr := range token.Attr {
+		if attr.Val != "" || indexOf(tag.ValueAttrs, attr.Key) == -1 {
+			continue
+		}
+		if valueless[attr.Key] || v.RejectEmptyValues {
+			v.report(s, tag.Name, attr.Key, "", pos, InvMissingAttributeValue)
+		}
+	}
+}
+
+// checkAllowedParents reports a tag whose parent is not in its
+// AllowedParents. Tags at the top level are not checked. pushed tells
+// whether the tag itself is on the parents stack.
+func (v *Validator) checkAllowedParents(s *validation, tag *ValidTag,
+	pos Span, pushed bool) {
+	i := len(s.parents) - 1
+	if pushed {
+		i--
+	}
+	for ; i >= 0; i-- {
+		parent := s.parents[i].name
+		if v.isValidSelfClosingTag(parent) || transparentElements[parent] {
+			continue
+		}
+		if indexOf(tag.AllowedParents, parent) == -1 {
+			cError := v.report(s, tag.Name, "", "", pos, InvIllegalNesting)
+			if cError != nil {
+				cError.Note = "found inside '" + parent + "', allowed in " +
+					strings.Join(tag.AllowedParents, ", ")
+			}
+		}
+		return
+	}
+}
+
+// checkRawAttrs runs the checks that need the attributes as written in the
+// source rather than as decoded by the tokenizer.
+func (v *Validator) checkRawAttrs(s *validation, tagName string, raw []byte,
+	pos Span) {
+	for _, attr := range parseRawAttrs(raw) {
+		key := strings.ToLower(attr.Key)
+		if v.CheckMalformedAttrs && attr.isMalformed() {
+			cError := v.report(s, tagName, key, attr.Val, pos,
+				InvMalformedAttrValue)
+			if cError != nil {
+				cError.Note = "the value is not closed by a matching quote"
+			}
+			continue
+		}
+		if v.CheckAttrEscaping && attr.hasUnescaped() {
+			v.report(s, tagName, key, attr.Val, pos, InvUnescapedInAttr)
+		}
+		if v.RequireDoubleQuotes && attr.HasValue && attr.Quote != '"' {
+			v.report(s, tagName, key, attr.Val, pos, InvAttrQuoteStyle)
+		}
+	}
+}
+
+// checkTruncatedTag looks at a start tag cut off by the end of the input,
+// which the tokenizer drops. This happens when a quoted value is never
+// closed.
+func (v *Validator) checkTruncatedTag(s *validation, raw []byte, pos Span) {
+	if len(raw) < 2 || raw[0] != '<' || !isLetter(raw[1]) {
+		return
+	}
+	end := 1
+	for end < len(raw) && !isSpace(raw[end]) && raw[end] != '/' &&
+		raw[end] != '>' {
+		end++
+	}
+	tagName := strings.ToLower(string(raw[1:end]))
+	for _, attr := range parseRawAttrs(raw) {
+		if attr.isMalformed() {
+			cError := v.report(s, tagName, strings.ToLower(attr.Key),
+				attr.Val, pos, InvMalformedAttrValue)
+			if cError != nil {
+				cError.Note = "the value runs to the end of the input"
+			}
+		}
+	}
+}
+
+// checkDataAttrValue matches the value of a declared data attribute against
+// its pattern.
+func (v *Validator) checkDataAttrValue(s *validation, tagName string,
+	key string, value string, pos Span) {
+	re := v.dataAttrs[key]
+	if re != nil && !re.MatchString(value) {
+		v.report(s, tagName, key, value, pos, InvBadDataAttrValue)
+	}
+}
+
+// skipping reports whether token is inside a SkipInside subtree and should
+// be ignored. Only the matching end tag of the subtree root gets through.
+func (s *validation) skipping(token html.Token) bool {
+	if token.Data != s.skipTag || token.Type == html.SelfClosingTagToken {
+		return true
+	}
+	switch token.Type {
+	case html.StartTagToken:
+		s.skipNesting++
+		return true
+	case html.EndTagToken:
+		if s.skipNesting > 0 {
+			s.skipNesting--
+			return true
+		}
+		s.skipTag = ""
+		return false
+	}
+	return true
+}
+
+// checkCharsetFirst verifies that <meta charset> is the first element in
+// <head>. It runs before token is pushed onto the parents.
+func (v *Validator) checkCharsetFirst(s *validation, token html.Token,
+	pos Span) {
+	if token.Data == "head" {
+		s.headChildren = 0
+		return
+	}
+	if s.parent() != "head" {
+		return
+	}
+	s.headChildren++
+	if token.Data == "meta" && hasAttr(token, "charset") &&
+		s.headChildren > 1 {
+		cError := v.report(s, token.Data, "charset", "", pos,
+			InvCharsetNotFirst)
+		if cError != nil {
+			cError.Note = "the charset declaration should come first in <head>"
+		}
+	}
 }
@@ -1,11 +1,16 @@
 package htmlcheck
 
 import (
+	"bytes"
+	"context"
 	"os"
+	"regexp"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/BlackEspresso/htmlcheck/htmlp"
+	"golang.org/x/text/encoding/charmap"
 )
 
 var v Validator = Validator{}
@@ -58,6 +63,23 @@ func hasErrors(t *testing.T, errors []*ValidationError, text string) {
 	t.Log(errors)
 }
 
+func hasReason(t *testing.T, errors []*ValidationError, reason ErrorReason) {
+	for _, e := range errors {
+		if e.Reason == reason {
+			return
+		}
+	}
+	t.Fatal("missing error reason", reason, errors)
+}
+
+func newValidator(tags ...ValidTag) *Validator {
+	val := &Validator{}
+	for _, tag := range tags {
+		val.AddValidTag(tag)
+	}
+	return val
+}
+
 func Test_SingleTag(t *testing.T) {
 	errors := v.ValidateHtmlString("<a></a>")
 	checkErrors(t, errors)
@@ -194,6 +216,1066 @@ func Test_Callback(t *testing.T) {
 	checkErrors(t, errors)
 }
 
+func Test_UnescapedInAttr(t *testing.T) {
+	val := newValidator(ValidTag{Name: "a", Attrs: []string{"title"}})
+	val.CheckAttrEscaping = true
+
+	errors := val.ValidateHtmlString(`<a title="a<b"></a>`)
+	hasReason(t, errors, InvUnescapedInAttr)
+
+	errors = val.ValidateHtmlString(`<a title=a"b></a>`)
+	hasReason(t, errors, InvUnescapedInAttr)
+
+	errors = val.ValidateHtmlString(`<a title="a&lt;b"></a>`)
+	checkErrors(t, errors)
+}
+
+func Test_MaxSelfNesting(t *testing.T) {
+	val := newValidator(ValidTag{Name: "p", MaxSelfNesting: 1},
+		ValidTag{Name: "section", MaxSelfNesting: 2})
+
+	errors := val.ValidateHtmlString("<p></p><p></p>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<p><p></p></p>")
+	hasReason(t, errors, InvExcessiveSelfNesting)
+
+	errors = val.ValidateHtmlString("<section><section></section></section>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(
+		"<section><section><section></section></section></section>")
+	hasReason(t, errors, InvExcessiveSelfNesting)
+}
+
+func Test_ButtonType(t *testing.T) {
+	val := newValidator(ValidTag{Name: "button", Attrs: []string{"type"}})
+
+	errors := val.ValidateHtmlString("<button>ok</button>")
+	checkErrors(t, errors)
+
+	val.CheckButtonType = true
+	errors = val.ValidateHtmlString("<button type='button'>ok</button>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<button>ok</button>")
+	hasReason(t, errors, InvImplicitButtonType)
+	if errors[0].Severity != SeverityWarning || errors[0].Note == "" {
+		t.Fatal("should be a warning with a note", errors[0])
+	}
+}
+
+func Test_ValidateHtmlEncoding(t *testing.T) {
+	// "<b>caf\xe9</b><b kkk='x'></b>" in ISO-8859-1, é is a single byte
+	val := newValidator(ValidTag{Name: "b"})
+	latin1 := []byte("<b>caf\xe9</b><b kkk='x'></b>")
+	errors := val.ValidateHtmlEncoding(bytes.NewReader(latin1),
+		charmap.ISO8859_1)
+	if len(errors) != 1 {
+		t.Fatal("should raise invalid attribute error", errors)
+	}
+	// é takes two bytes once decoded to UTF-8
+	if errors[0].Pos.Start != 13 {
+		t.Fatal(errors[0].Pos)
+	}
+}
+
+func Test_CommentPatterns(t *testing.T) {
+	val := newValidator(ValidTag{Name: "b"})
+	val.DisallowedComment = regexp.MustCompile(`(?i)todo|password`)
+
+	errors := val.ValidateHtmlString("<b></b><!-- build:js app.js -->")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<b></b><!-- TODO remove -->")
+	hasReason(t, errors, InvDisallowedComment)
+
+	val.DisallowedComment = nil
+	val.AllowedComment = regexp.MustCompile(`^\s*(build|endbuild)`)
+	errors = val.ValidateHtmlString("<!-- build:js app.js --><!-- endbuild -->")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<!-- debug -->")
+	hasReason(t, errors, InvDisallowedComment)
+}
+
+func Test_CharsetFirst(t *testing.T) {
+	val := newValidator(ValidTag{Name: "head"}, ValidTag{Name: "title"},
+		ValidTag{Name: "meta", Attrs: []string{"charset", "name", "content"},
+			IsSelfClosing: true})
+	val.CheckCharsetFirst = true
+
+	errors := val.ValidateHtmlString(
+		"<head><!-- c --><meta charset='utf-8'><title>t</title></head>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(
+		"<head><title>t</title><meta charset='utf-8'></head>")
+	hasReason(t, errors, InvCharsetNotFirst)
+}
+
+func Test_LinkRel(t *testing.T) {
+	val := newValidator(ValidTag{Name: "link", Attrs: []string{"rel", "href"},
+		IsSelfClosing: true}, ValidTag{Name: "a", Attrs: []string{"rel", "href"}})
+	val.CheckRel = true
+
+	errors := val.ValidateHtmlString("<link rel='stylesheet' href='a.css'>" +
+		"<link rel='preconnect' href='https://cdn'>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<a rel='noopener  NoReferrer' href='/'></a>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<link rel='styleshet' href='a.css'>")
+	hasReason(t, errors, InvBadRel)
+
+	errors = val.ValidateHtmlString("<a rel='nofollow noopenr' href='/'></a>")
+	hasReason(t, errors, InvBadRel)
+	if len(errors) != 1 || errors[0].Reason != InvBadRel {
+		t.Fatal("should only flag the unknown token", errors)
+	}
+}
+
+func Test_SkipInside(t *testing.T) {
+	val := newValidator(ValidTag{Name: "div"}, ValidTag{Name: "b"},
+		ValidTag{Name: "widget"})
+	val.SkipInside = []string{"widget"}
+
+	errors := val.ValidateHtmlString("<div><widget><x-foo bar=1><i>" +
+		"<widget></widget></b></widget></div>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<div><widget><x-foo></div>")
+	hasReason(t, errors, InvNotProperlyClosed)
+
+	errors = val.ValidateHtmlString("<widget></widget><x-foo></x-foo>")
+	hasReason(t, errors, InvTag)
+}
+
+func Test_MaxErrors(t *testing.T) {
+	val := newValidator(ValidTag{Name: "b"})
+	val.MaxErrors = 3
+
+	errors := val.ValidateHtmlString(strings.Repeat("<art>", 10))
+	if len(errors) != 3 {
+		t.Fatal("expected the first three errors", len(errors))
+	}
+
+	val.MaxErrors = 0
+	errors = val.ValidateHtmlString(strings.Repeat("<art>", 10))
+	if len(errors) != 10 {
+		t.Fatal("zero should not limit the errors", len(errors))
+	}
+}
+
+func Test_ErrorsPerToken(t *testing.T) {
+	val := newValidator(ValidTag{Name: "b", Attrs: []string{"id"}})
+
+	errors := val.ValidateHtmlString(`<b x="1" y="2" id="a" id="b"></b>`)
+	if len(errors) != 3 || errors[0].AttributeName != "x" ||
+		errors[1].AttributeName != "y" ||
+		errors[2].Reason != InvDuplicatedAttribute {
+		t.Fatal("expected an error for every failed check", errors)
+	}
+
+	val.MaxErrors = 2
+	errors = val.ValidateHtmlString(`<b x="1" y="2" id="a" id="b"></b>`)
+	if len(errors) != 2 {
+		t.Fatal("MaxErrors should stop inside a tag", errors)
+	}
+
+	val.MaxErrors = 0
+	val.StopAfterFirstError = true
+	errors = val.ValidateHtmlString(`<b x="1" y="2"><i></b>`)
+	if len(errors) != 1 || errors[0].AttributeName != "x" {
+		t.Fatal("expected only the first error", errors)
+	}
+}
+
+func Test_TreatWarningsAsErrors(t *testing.T) {
+	val := newValidator(ValidTag{Name: "button"}, ValidTag{Name: "b"})
+	val.CheckButtonType = true
+	val.StopAfterFirstError = true
+
+	errors := val.ValidateHtmlString("<button></button><art>")
+	if len(errors) != 2 || errors[0].Severity != SeverityWarning {
+		t.Fatal("warnings should not stop validation", errors)
+	}
+
+	val.TreatWarningsAsErrors = true
+	errors = val.ValidateHtmlString("<button></button><art>")
+	if len(errors) != 1 || errors[0].Reason != InvImplicitButtonType ||
+		errors[0].Severity != SeverityError {
+		t.Fatal("warning should be escalated to an error", errors)
+	}
+}
+
+func Test_RecommendedAttrs(t *testing.T) {
+	val := newValidator(ValidTag{Name: "abbr", RecommendedAttrs: []string{"title"}})
+
+	errors := val.ValidateHtmlString("<abbr title='HyperText Markup Language'>HTML</abbr>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<abbr>HTML</abbr>")
+	hasReason(t, errors, InvMissingRecommendedAttr)
+	if errors[0].AttributeName != "title" || errors[0].Severity != SeverityWarning {
+		t.Fatal(errors[0])
+	}
+}
+
+func Test_RequiredAttrs(t *testing.T) {
+	val := newValidator(ValidTag{Name: "img", Attrs: []string{"alt"},
+		RequiredAttrs: []string{"src"}, IsSelfClosing: true})
+
+	errors := val.ValidateHtmlString("<img src='a.png' alt=''><img src='b.png'/>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<img alt=''>")
+	hasReason(t, errors, InvMissingAttribute)
+	if errors[0].AttributeName != "src" || errors[0].TagName != "img" {
+		t.Fatal(errors[0])
+	}
+
+	errors = val.ValidateHtmlString("<img alt=''/>")
+	hasReason(t, errors, InvMissingAttribute)
+}
+
+func Test_AttrAliases(t *testing.T) {
+	val := newValidator(ValidTag{Name: "b", Attrs: []string{"data-testid"}})
+	val.AttrAliases = map[string]string{"data-test-id": "data-testid"}
+
+	errors := val.ValidateHtmlString("<b data-test-id='x'></b>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<b data-test-key='x'></b>")
+	hasReason(t, errors, InvAttribute)
+
+	errors = val.ValidateHtmlString("<b data-testid='x' data-test-id='y'></b>")
+	hasReason(t, errors, InvDuplicatedAttribute)
+
+	val.WarnOnAttrAlias = true
+	errors = val.ValidateHtmlString("<b data-test-id='x'></b>")
+	hasReason(t, errors, InvAttributeAlias)
+	if errors[0].Severity != SeverityWarning {
+		t.Fatal(errors[0])
+	}
+}
+
+func Test_VoidEndTags(t *testing.T) {
+	val := newValidator(ValidTag{Name: "br", IsSelfClosing: true})
+
+	errors := val.ValidateHtmlString("<br></br>")
+	checkErrors(t, errors)
+
+	val.CheckVoidEndTags = true
+	errors = val.ValidateHtmlString("<br>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<br></br>")
+	hasReason(t, errors, InvEndTagForVoid)
+	if len(errors) != 1 || errors[0].Severity != SeverityWarning {
+		t.Fatal(errors)
+	}
+
+	errors = val.ValidateHtmlString("<br/></BR>")
+	hasReason(t, errors, InvEndTagOnVoidElement)
+}
+
+func Test_SelfClosingNonVoid(t *testing.T) {
+	val := newValidator(ValidTag{Name: "div"}, ValidTag{Name: "p"},
+		ValidTag{Name: "br", IsSelfClosing: true})
+
+	errors := val.ValidateHtmlString("<div/><p></p></div>")
+	checkErrors(t, errors)
+
+	val.CheckSelfClosingSyntax = true
+	errors = val.ValidateHtmlString("<br/><div/><p></p></div>")
+	if len(errors) != 1 || errors[0].Reason != InvSelfClosingNonVoid ||
+		errors[0].Severity != SeverityWarning {
+		t.Fatal("expected one warning and the div to stay open", errors)
+	}
+}
+
+func Test_RequireDoubleQuotes(t *testing.T) {
+	val := newValidator(ValidTag{Name: "a", Attrs: []string{"href", "download"}})
+	val.RequireDoubleQuotes = true
+
+	errors := val.ValidateHtmlString(`<a href="/x" download></a>`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<a href='/x'></a>`)
+	hasReason(t, errors, InvAttrQuoteStyle)
+
+	errors = val.ValidateHtmlString(`<a href=/x></a>`)
+	hasReason(t, errors, InvAttrQuoteStyle)
+}
+
+func Test_UnclosedSpan(t *testing.T) {
+	val := newValidator(ValidTag{Name: "b", Attrs: []string{"id"}},
+		ValidTag{Name: "c"})
+
+	str := "<c><b id='x'>text</c>"
+	errors := val.ValidateHtmlString(str)
+	hasReason(t, errors, InvNotProperlyClosed)
+	if span := errors[0].Pos; str[span.Start:span.End] != "<b id='x'>" {
+		t.Fatal(errors[0].Pos)
+	}
+
+	str = "<c></c>\n<b id='x'>text"
+	errors = val.ValidateHtmlString(str)
+	hasReason(t, errors, InvNotProperlyClosed)
+	if span := errors[0].Pos; str[span.Start:span.End] != "<b id='x'>" {
+		t.Fatal(errors[0].Pos)
+	}
+}
+
+func Test_DataAttrSchema(t *testing.T) {
+	val := newValidator(ValidTag{Name: "", AttrStartsWith: "data-"},
+		ValidTag{Name: "button"})
+
+	errors := val.ValidateHtmlString("<button data-tgogle='modal'></button>")
+	checkErrors(t, errors)
+
+	err := val.AddDataAttrs(map[string]string{
+		"data-toggle": "modal|collapse",
+		"data-target": "",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	errors = val.ValidateHtmlString(
+		"<button data-toggle='modal' data-target='#x'></button>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<button data-tgogle='modal'></button>")
+	hasReason(t, errors, InvAttribute)
+
+	errors = val.ValidateHtmlString("<button data-toggle='modals'></button>")
+	hasReason(t, errors, InvBadDataAttrValue)
+
+	if val.AddDataAttrs(map[string]string{"data-x": "("}) == nil {
+		t.Fatal("invalid pattern should be rejected")
+	}
+}
+
+func Test_MeaningfulContent(t *testing.T) {
+	val := newValidator()
+	if val.IsMeaningfulContent(" \n\t") {
+		t.Fatal("whitespace should not be meaningful")
+	}
+	if val.IsMeaningfulContent("\u00a0") {
+		t.Fatal("nbsp should not be meaningful by default")
+	}
+	if !val.IsMeaningfulContent(" text ") {
+		t.Fatal("non-whitespace text should be meaningful by default")
+	}
+
+	val.MeaningfulContent = func(text string) bool {
+		return strings.Trim(text, " \n\r\t\f") != ""
+	}
+	if !val.IsMeaningfulContent("\u00a0") {
+		t.Fatal("custom policy should treat nbsp as content")
+	}
+}
+
+func Test_Fingerprint(t *testing.T) {
+	val := newValidator(ValidTag{Name: "a"})
+
+	first := val.ValidateHtmlString(`<a href="x"></a>`)
+	second := val.ValidateHtmlString(`<a href="x"></a>`)
+	if len(first) != 1 || len(second) != 1 {
+		t.Fatal("expected one error per run")
+	}
+	if first[0].Fingerprint() != second[0].Fingerprint() {
+		t.Fatal("fingerprint should be stable across runs")
+	}
+
+	second[0].Note = "changed"
+	second[0].Severity = SeverityWarning
+	if first[0].Fingerprint() != second[0].Fingerprint() {
+		t.Fatal("fingerprint should ignore severity and note")
+	}
+
+	other := val.ValidateHtmlString(`<a id="x"></a>`)
+	if len(other) != 1 || other[0].Fingerprint() == first[0].Fingerprint() {
+		t.Fatal("different errors should have different fingerprints")
+	}
+}
+
+func Test_UnknownTagsAreVoid(t *testing.T) {
+	val := newValidator(ValidTag{Name: "div"}, ValidTag{Name: "span"})
+	val.RegisterCallback(func(tagName string, attributeName string,
+		value string, reason ErrorReason) *ValidationError {
+		if reason == InvTag {
+			return nil
+		}
+		return &ValidationError{TagName: tagName, Reason: reason}
+	})
+
+	html := "<div><x-widget><span></span></div>"
+	errors := val.ValidateHtmlString(html)
+	hasReason(t, errors, InvNotProperlyClosed)
+
+	val.UnknownTagsAreVoid = true
+	errors = val.ValidateHtmlString(html)
+	checkErrors(t, errors)
+}
+
+func Test_AllowUnknownTags(t *testing.T) {
+	val := newValidator(ValidTag{Name: "div"}, ValidTag{Name: "span"})
+	val.AllowUnknownTags = true
+	val.StopAfterFirstError = true
+
+	errors := val.ValidateHtmlString(`<div><x-card size="2"><span></x-card></div>`)
+	if len(errors) != 2 || errors[0].Reason != InvTag ||
+		errors[0].Severity != SeverityWarning ||
+		errors[1].Reason != InvNotProperlyClosed || errors[1].TagName != "span" {
+		t.Fatal("expected a warning and the nesting error inside", errors)
+	}
+}
+
+func Test_MalformedAttrValue(t *testing.T) {
+	val := newValidator(ValidTag{Name: "a", Attrs: []string{"title", "class"}})
+	val.CheckMalformedAttrs = true
+
+	errors := val.ValidateHtmlString(`<a title="it's" class='x'></a>`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<a title="foo' class="bar"></a>`)
+	hasReason(t, errors, InvMalformedAttrValue)
+
+	errors = val.ValidateHtmlString(`<a title="foo'></a>`)
+	hasReason(t, errors, InvMalformedAttrValue)
+}
+
+func Test_HardMaxDepth(t *testing.T) {
+	val := newValidator(ValidTag{Name: "div"}, ValidTag{Name: "br", IsSelfClosing: true})
+	val.HardMaxDepth = 3
+
+	errors := val.ValidateHtmlString("<div><br><br><br><div><div></div></div></div>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(strings.Repeat("<div>", 100000))
+	if len(errors) != 1 || errors[0].Reason != InvMaxDepthExceeded {
+		t.Fatal("expected a single depth error", len(errors))
+	}
+}
+
+func Test_MaxDepth(t *testing.T) {
+	val := newValidator(ValidTag{Name: "div"}, ValidTag{Name: "br", IsSelfClosing: true})
+	val.MaxDepth = 2
+
+	errors := val.ValidateHtmlString("<div><br><div></div></div>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<div><br><div><div><div></div></div><p></div></div>")
+	if len(errors) != 2 || errors[0].Reason != InvMaxDepthExceeded ||
+		errors[1].Reason != InvTag {
+		t.Fatal("expected the depth error and validation to go on", errors)
+	}
+
+	val.StopAfterFirstError = true
+	errors = val.ValidateHtmlString("<div><div><div><p></div></div></div>")
+	if len(errors) != 1 || errors[0].Reason != InvMaxDepthExceeded {
+		t.Fatal(errors)
+	}
+}
+
+func Test_MaxAttrsPerTag(t *testing.T) {
+	val := newValidator(ValidTag{Name: "div", AttrRegEx: "^a"})
+	val.MaxAttrsPerTag = 2
+
+	errors := val.ValidateHtmlString(`<div a1 a2></div>`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<div a1 a2 b3 b3></div>`)
+	if len(errors) != 1 || errors[0].Reason != InvTooManyAttributes {
+		t.Fatal("attributes past the limit should not be checked", errors)
+	}
+
+	notes := []string{}
+	val.ValidateHtmlStream(strings.NewReader(`<div a1 a2 a3></div>`),
+		func(err *ValidationError) bool {
+			notes = append(notes, err.Note)
+			return true
+		})
+	if len(notes) != 1 || notes[0] != "3 attributes, at most 2 allowed" {
+		t.Fatal("the note should be streamed", notes)
+	}
+}
+
+func Test_RequireScriptNonce(t *testing.T) {
+	val := newValidator(ValidTag{Name: "script",
+		Attrs: []string{"src", "nonce", "type"}})
+	val.RequireScriptNonce = true
+
+	errors := val.ValidateHtmlString(`<script nonce="r4nd0m">run()</script>` +
+		`<script src="/app.js"></script>` +
+		`<script type="application/ld+json">{}</script>` +
+		`<script type="application/json">{}</script>`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<script>run()</script>`)
+	hasReason(t, errors, InvMissingNonce)
+
+	errors = val.ValidateHtmlString(`<script type="module">run()</script>` +
+		`<script type="Text/JavaScript; charset=utf-8">run()</script>`)
+	if len(errors) != 2 {
+		t.Fatal("expected both scripts to need a nonce", errors)
+	}
+}
+
+func Test_ContextCallback(t *testing.T) {
+	val := newValidator(ValidTag{Name: "div"}, ValidTag{Name: "p"})
+
+	var ctx *Context
+	var ancestors []string
+	val.RegisterContextCallback(func(c *Context, tagName string,
+		attributeName string, value string, reason ErrorReason) *ValidationError {
+		if ctx == nil {
+			ctx = c
+			ancestors = c.Ancestors()
+		}
+		return nil
+	})
+
+	errors := val.ValidateHtmlString("<div><p><kk></kk></p></div>")
+	checkErrors(t, errors)
+	if strings.Join(ancestors, ",") != "div,p" {
+		t.Fatal("unexpected ancestors", ancestors)
+	}
+	if ctx.Ancestors() != nil {
+		t.Fatal("context should not be usable after the callback")
+	}
+}
+
+func Test_ContextPosition(t *testing.T) {
+	val := newValidator(ValidTag{Name: "p"})
+	val.RegisterContextCallback(func(c *Context, tagName string,
+		attributeName string, value string, reason ErrorReason) *ValidationError {
+		if c.TextPos().Line == 2 {
+			return nil
+		}
+		return &ValidationError{TagName: tagName, Reason: reason, Pos: c.Pos()}
+	})
+
+	errors := val.ValidateHtmlString("<p><kk></p>\n<p><embed></p>")
+	if len(errors) != 1 || errors[0].TagName != "kk" || errors[0].Pos != (Span{4, 6}) {
+		t.Fatal("expected errors on line 2 to be suppressed", errors)
+	}
+}
+
+func Test_MaxChainDepth(t *testing.T) {
+	val := newValidator(ValidTag{Name: "table"}, ValidTag{Name: "tr"}, ValidTag{Name: "td"})
+	val.MaxChainDepth = map[string]int{"table": 2}
+
+	errors := val.ValidateHtmlString("<table><tr><td><table></table></td></tr></table>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<table><tr><td><table><tr><td><table></table>" +
+		"</td></tr></table></td></tr></table>")
+	hasReason(t, errors, InvExcessiveNesting)
+}
+
+func Test_AttrValueRegEx(t *testing.T) {
+	val := newValidator(ValidTag{Name: "input", Attrs: []string{"type", "name"},
+		AttrValueRegEx: map[string]string{"type": "^(text|password|checkbox|radio)$"},
+		IsSelfClosing:  true})
+
+	errors := val.ValidateHtmlString(`<input type="text" name="q"><input name="x">`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<input type="txt">`)
+	hasReason(t, errors, InvAttributeValue)
+
+	err := val.AddValidTag(ValidTag{Name: "select",
+		AttrValueRegEx: map[string]string{"size": "[0-9"}})
+	if err == nil || val.IsValidTag("select") {
+		t.Fatal("expected a pattern error", err)
+	}
+}
+
+func Test_SuggestAttributes(t *testing.T) {
+	val := newValidator(ValidTag{Name: "", Attrs: []string{"class", "id"}},
+		ValidTag{Name: "a", Attrs: []string{"href"}})
+	val.SuggestAttributes = true
+
+	errors := val.ValidateHtmlString(`<a clss="x" hre="/"></a>`)
+	if len(errors) != 2 || errors[0].Note != "did you mean 'class'?" ||
+		errors[1].Note != "did you mean 'href'?" {
+		t.Fatal("expected suggestions", errors)
+	}
+
+	errors = val.ValidateHtmlString(`<a onclick="x()"></a>`)
+	hasReason(t, errors, InvAttribute)
+	if errors[0].Note != "" {
+		t.Fatal("no suggestion expected", errors[0].Note)
+	}
+
+	val = newValidator(ValidTag{Name: "a", Attrs: []string{"href"}})
+	val.SuggestAttributes = true
+	val.AddGlobalAttrs([]string{"class"})
+	val.AddDataAttrs(map[string]string{"data-toggle": ""})
+	errors = val.ValidateHtmlString(`<a clss="x" data-tgogle="y"></a>`)
+	if len(errors) != 2 || errors[0].Note != "did you mean 'class'?" ||
+		errors[1].Note != "did you mean 'data-toggle'?" {
+		t.Fatal("expected global and data attribute suggestions", errors)
+	}
+}
+
+func Test_AttrRegEx(t *testing.T) {
+	val := newValidator(ValidTag{Name: "div", AttrRegEx: "^data-[a-z]+$"})
+
+	errors := val.ValidateHtmlString(`<div data-id="1" data-role="x"></div>`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<div data-ID2="1"></div>`)
+	hasReason(t, errors, InvAttribute)
+
+	err := val.AddValidTag(ValidTag{Name: "span", AttrRegEx: "(data-"})
+	if err == nil || val.IsValidTag("span") {
+		t.Fatal("expected a pattern error", err)
+	}
+}
+
+func Test_AddValidTagsDuplicates(t *testing.T) {
+	val := newValidator(ValidTag{Name: ""}, ValidTag{Name: "a"})
+
+	if err := val.AddValidTag(ValidTag{Name: ""}); err == nil {
+		t.Fatal("expected an error for a second global tag")
+	}
+	if err := val.AddValidTag(ValidTag{Name: "a"}); err == nil {
+		t.Fatal("expected an error for a duplicated tag")
+	}
+	err := val.AddValidTags([]*ValidTag{{Name: "b"}, {Name: "b"}})
+	if err == nil || val.IsValidTag("b") {
+		t.Fatal("expected an error for a tag defined twice", err)
+	}
+}
+
+func Test_LoadTagsFromJSON(t *testing.T) {
+	val := &Validator{}
+	err := val.LoadTagsFromJSON(strings.NewReader(`[
+		{"name": "a", "attrs": ["href"], "requiredAttrs": ["href"]},
+		{"name": "img", "attrRegEx": "^(src|alt)$", "isSelfClosing": true}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errors := val.ValidateHtmlString(`<a href="/"><img src="a.png" alt=""></a>`)
+	checkErrors(t, errors)
+
+	for _, input := range []string{`{"name": "a"}`, `[{"name": "a", "atrs": []}]`,
+		`[{"name": "a"}`, `[null]`, `[] []`} {
+		if err := (&Validator{}).LoadTagsFromJSON(strings.NewReader(input)); err == nil {
+			t.Fatal("expected an error for", input)
+		}
+	}
+}
+
+func Test_ReaderTextPos(t *testing.T) {
+	val := newValidator(ValidTag{Name: "a"})
+	str := "<a>\r\n<kk>\n\n  <a kkk='1'\n x></a></kk>\n<b>"
+
+	errors := val.ValidateHtml(strings.NewReader(str))
+	if len(errors) == 0 {
+		t.Fatal("expected errors")
+	}
+	positions := []TextPos{}
+	for _, e := range errors {
+		if e.TextPos == nil {
+			t.Fatal("missing TextPos", e)
+		}
+		positions = append(positions, *e.TextPos)
+	}
+
+	UpdateErrorLines(str, errors)
+	for i, e := range errors {
+		if *e.TextPos != positions[i] {
+			t.Fatal("TextPos differs from UpdateErrorLines", e, positions[i])
+		}
+	}
+}
+
+func Test_EarlierTextPos(t *testing.T) {
+	val := HTML5Validator()
+	val.AddCloseRule("ul", func(node *Node) []*ValidationError {
+		return []*ValidationError{{TagName: "li", Reason: InvTag,
+			Pos: node.Children[1].Pos}}
+	})
+	str := "<div>\n <span>\n  <ul>\n<li>a\n  <li>b</ul>\n</div>\n<div>"
+
+	errors := val.ValidateHtmlString(str)
+	hasReason(t, errors, InvNotProperlyClosed)
+	hasReason(t, errors, InvTag)
+	positions := []TextPos{}
+	for _, e := range errors {
+		if e.TextPos == nil {
+			t.Fatal("missing TextPos", e)
+		}
+		positions = append(positions, *e.TextPos)
+	}
+
+	UpdateErrorLines(str, errors)
+	for i, e := range errors {
+		if *e.TextPos != positions[i] {
+			t.Fatal("TextPos differs from UpdateErrorLines", e, positions[i])
+		}
+	}
+}
+
+func Test_UpdateErrorLinesOffsets(t *testing.T) {
+	str := "ab\n\ncd"
+	expected := map[int]TextPos{0: {1, 1}, 2: {1, 3}, 3: {2, 1}, 4: {3, 1},
+		6: {3, 3}}
+	for offset, pos := range expected {
+		errors := []*ValidationError{{Pos: Span{offset, offset}}}
+		UpdateErrorLines(str, errors)
+		if errors[0].TextPos == nil || *errors[0].TextPos != pos {
+			t.Fatal("unexpected position for offset", offset, errors[0].TextPos)
+		}
+	}
+
+	errors := []*ValidationError{{Pos: Span{7, 7}}}
+	UpdateErrorLines(str, errors)
+	if errors[0].TextPos != nil {
+		t.Fatal("offsets past the end should have no position")
+	}
+}
+
+func Test_TextPosCountsRunes(t *testing.T) {
+	val := newValidator(ValidTag{Name: "p"})
+	str := "<p>\nCafé 🙂 <p kkk='1'></p></p>"
+
+	errors := val.ValidateHtmlString(str)
+	hasReason(t, errors, InvAttribute)
+	if *errors[0].TextPos != (TextPos{2, 9}) {
+		t.Fatal("column should count runes", errors[0].TextPos)
+	}
+
+	UpdateErrorLines(str, errors)
+	if *errors[0].TextPos != (TextPos{2, 9}) {
+		t.Fatal("column should count runes", errors[0].TextPos)
+	}
+}
+
+func Test_Severities(t *testing.T) {
+	val := newValidator(ValidTag{Name: "a"}, ValidTag{Name: "abbr", RecommendedAttrs: []string{"title"}})
+	val.Severities = map[ErrorReason]Severity{
+		InvAttribute:              SeverityWarning,
+		InvMissingRecommendedAttr: SeverityError,
+	}
+
+	errors := val.ValidateHtmlString(`<a kkk="1"></a><abbr></abbr><kk></kk>`)
+	if len(errors) != 4 {
+		t.Fatal("expected all errors", errors)
+	}
+	expected := []Severity{SeverityWarning, SeverityError, SeverityError, SeverityError}
+	for i, e := range errors {
+		if e.Severity != expected[i] {
+			t.Fatal("unexpected severity", e, e.Severity)
+		}
+	}
+}
+
+func Test_AllowedParents(t *testing.T) {
+	val := newValidator(ValidTag{Name: "ul"}, ValidTag{Name: "div"},
+		ValidTag{Name: "ins"}, ValidTag{Name: "li", AllowedParents: []string{"ul", "ol"}})
+
+	errors := val.ValidateHtmlString("<li></li><ul><li></li><ins><li></li></ins></ul>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<div><li></li></div>")
+	hasReason(t, errors, InvIllegalNesting)
+	if !strings.Contains(errors[0].Error(), "inside 'div'") {
+		t.Fatal("the parent should be in the message", errors[0].Error())
+	}
+}
+
+func Test_ConcurrentValidation(t *testing.T) {
+	val := newValidator(ValidTag{Name: "div", Attrs: []string{"id"}},
+		ValidTag{Name: "br", IsSelfClosing: true})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				errors := val.ValidateHtmlString("<div id='a'><br></div><span>")
+				if len(errors) != 1 || errors[0].Reason != InvTag {
+					t.Error("unexpected errors", errors)
+					return
+				}
+				if !val.IsValidTag("div") || !val.IsValidSelfClosingTag("br") ||
+					!val.IsValidAttribute("div", "id") {
+					t.Error("tags should stay valid")
+					return
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		val.AddValidTag(ValidTag{Name: "b"})
+		val.RegisterCallback(nil)
+	}()
+	wg.Wait()
+}
+
+func Test_ValidateBytes(t *testing.T) {
+	val := newValidator(ValidTag{Name: "p"})
+
+	errors := val.ValidateBytes([]byte("<p>\n  <art></p>"))
+	hasReason(t, errors, InvTag)
+	if errors[0].TextPos == nil || errors[0].TextPos.Line != 2 ||
+		errors[0].TextPos.Column != 4 {
+		t.Fatal("expected line 2, column 4", errors[0])
+	}
+}
+
+func Test_ValidateHtmlStream(t *testing.T) {
+	val := newValidator(ValidTag{Name: "p"})
+
+	count := 0
+	err := val.ValidateHtmlStream(strings.NewReader("<art><art><art>"),
+		func(e *ValidationError) bool {
+			count++
+			return count < 2
+		})
+	if err != nil || count != 2 {
+		t.Fatal("expected to stop after the second error", count, err)
+	}
+}
+
+func Test_RemoveTag(t *testing.T) {
+	val := newValidator(ValidTag{Name: "p"}, ValidTag{Name: "iframe", Attrs: []string{"src"}},
+		ValidTag{Name: "br", IsSelfClosing: true})
+
+	val.RemoveTag("iframe")
+	val.RemoveTag("br")
+	val.RemoveTag("nope")
+	(&Validator{}).RemoveTag("p")
+
+	if val.IsValidTag("iframe") || val.IsValidSelfClosingTag("br") || !val.IsValidTag("p") {
+		t.Fatal("tags not removed")
+	}
+	errors := val.ValidateHtmlString("<p><iframe src='x'></iframe></p>")
+	hasReason(t, errors, InvTag)
+
+	if err := val.AddValidTag(ValidTag{Name: "iframe"}); err != nil {
+		t.Fatal("a removed tag should be addable again", err)
+	}
+}
+
+func Test_AttributeValue(t *testing.T) {
+	val := newValidator(ValidTag{Name: "a", Attrs: []string{"href"}})
+
+	errors := val.ValidateHtmlString(`<a href="/x" href="/y" rel></a>`)
+	if len(errors) != 2 || errors[0].AttributeValue != "/y" ||
+		errors[1].AttributeValue != "" {
+		t.Fatal("expected the duplicated value", errors)
+	}
+	if !strings.Contains(errors[0].Error(), "with value '/y'") ||
+		strings.Contains(errors[1].Error(), "with value") {
+		t.Fatal(errors[0].Error(), errors[1].Error())
+	}
+}
+
+func Test_UnknownReasonError(t *testing.T) {
+	e := &ValidationError{TagName: "b", Reason: ErrorReason(999)}
+	if !strings.HasPrefix(e.Error(), "ErrorReason(999) in tag 'b'") {
+		t.Fatal(e.Error())
+	}
+}
+
+func Test_ValueAttrs(t *testing.T) {
+	val := newValidator(ValidTag{Name: "a", ValueAttrs: []string{"href"}},
+		ValidTag{Name: "input", Attrs: []string{"disabled"}, IsSelfClosing: true})
+
+	errors := val.ValidateHtmlString(`<a href="/x"></a><a href=""></a><input disabled>`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<a HREF></a>`)
+	hasReason(t, errors, InvMissingAttributeValue)
+
+	val.RejectEmptyValues = true
+	errors = val.ValidateHtmlString(`<a href=''></a>`)
+	hasReason(t, errors, InvMissingAttributeValue)
+}
+
+func Test_GlobalAttrs(t *testing.T) {
+	val := newValidator(ValidTag{Name: "p"}, ValidTag{Name: "b", Attrs: []string{"title"}})
+	val.AddGlobalAttrs([]string{"class", "id"})
+	if err := val.AddGlobalAttrRegEx("^data-"); err != nil {
+		t.Fatal(err)
+	}
+	if err := val.AddGlobalAttrRegEx("("); err == nil {
+		t.Fatal("expected an error for a bad pattern")
+	}
+
+	errors := val.ValidateHtmlString(`<p id="a" class="b" data-x="1"><b title="t" class="c"></b></p>`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<p title="t"></p>`)
+	hasReason(t, errors, InvAttribute)
+}
+
+func Test_CheckComments(t *testing.T) {
+	val := newValidator(ValidTag{Name: "p"})
+
+	errors := val.ValidateHtmlString("<p></p><!-- open")
+	checkErrors(t, errors)
+
+	val.CheckComments = true
+	errors = val.ValidateHtmlString("<!-- a - b --><!----><?xml version='1.0'?><p></p>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<p></p><!-- open")
+	hasReason(t, errors, InvMalformedComment)
+	if errors[0].Pos != (Span{7, 16}) {
+		t.Fatal("expected the span of the comment", errors[0].Pos)
+	}
+
+	for _, comment := range []string{"<!-- a -- b -->", "<!-->", "<!--->"} {
+		errors = val.ValidateHtmlString(comment)
+		hasReason(t, errors, InvMalformedComment)
+	}
+}
+
+func Test_DeprecatedTag(t *testing.T) {
+	val := newValidator(ValidTag{Name: "p"}, ValidTag{Name: "center", Deprecated: true})
+
+	errors := val.ValidateHtmlString("<center><p></p></center>")
+	if len(errors) != 1 || errors[0].Reason != InvDeprecatedTag ||
+		errors[0].Severity != SeverityWarning {
+		t.Fatal("expected a single warning for the start tag", errors)
+	}
+}
+
+func Test_BlockEventHandlers(t *testing.T) {
+	val := newValidator(ValidTag{Name: "img", AttrRegEx: ".*", IsSelfClosing: true})
+
+	errors := val.ValidateHtmlString(`<img src="x" onerror="alert(1)">`)
+	checkErrors(t, errors)
+
+	val.BlockEventHandlers = true
+	errors = val.ValidateHtmlString(`<img src="x" OnError="alert(1)">`)
+	if len(errors) != 1 || errors[0].Reason != InvEventHandler ||
+		errors[0].AttributeName != "onerror" {
+		t.Fatal(errors)
+	}
+}
+
+func Test_ValidateHtmlContext(t *testing.T) {
+	val := newValidator(ValidTag{Name: "p"})
+
+	errors, err := val.ValidateHtmlContext(context.Background(), strings.NewReader("<p><art></p>"))
+	if err != nil || len(errors) != 1 {
+		t.Fatal(errors, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	errors, err = val.ValidateHtmlContext(ctx, strings.NewReader(strings.Repeat("<p><art></p>", 1000)))
+	if err != context.Canceled || len(errors) != 0 {
+		t.Fatal("expected to stop right away", len(errors), err)
+	}
+}
+
+func Test_DisableReason(t *testing.T) {
+	val := newValidator(ValidTag{Name: "a", Attrs: []string{"href"}})
+	html := `<a href="x" href="y" title="t"></a>`
+
+	val.DisableReason(InvDuplicatedAttribute)
+	errors := val.ValidateHtmlString(html)
+	if len(errors) != 1 || errors[0].Reason != InvAttribute {
+		t.Fatal(errors)
+	}
+
+	val.EnableReason(InvDuplicatedAttribute)
+	errors = val.ValidateHtmlString(html)
+	hasReason(t, errors, InvDuplicatedAttribute)
+}
+
+func Test_ExpectedTag(t *testing.T) {
+	val := newValidator(ValidTag{Name: "b"}, ValidTag{Name: "i"}, ValidTag{Name: "p"})
+
+	errors := val.ValidateHtmlString("<b><i></b></i>")
+	if len(errors) != 2 || errors[0].ExpectedTag != "i" || errors[1].ExpectedTag != "" {
+		t.Fatal(errors)
+	}
+	if !strings.HasSuffix(errors[0].Error(), ": expected </i> but found </b>") {
+		t.Fatal(errors[0].Error())
+	}
+
+	errors = val.ValidateHtmlString("<p><b></i></b></p>")
+	if len(errors) != 1 || errors[0].Reason != InvClosedBeforeOpened ||
+		errors[0].ExpectedTag != "b" {
+		t.Fatal(errors)
+	}
+	streamed := []*ValidationError{}
+	val.ValidateHtmlStream(strings.NewReader("<b><i></b></i>"),
+		func(err *ValidationError) bool {
+			streamed = append(streamed, &ValidationError{
+				ExpectedTag: err.ExpectedTag, Note: err.Note})
+			return true
+		})
+	if len(streamed) != 2 || streamed[0].ExpectedTag != "i" ||
+		streamed[0].Note == "" {
+		t.Fatal("details should be set before the error is streamed",
+			streamed)
+	}
+}
+
+func Test_ExpectedTagDiffers(t *testing.T) {
+	val := HTML5Validator()
+	for _, str := range []string{"<ul><li>x<ul><li>y</ul></li></ul>",
+		"<ul><li>x<ul><li>y</ul></ul></ul>", "<b><i><b></i></b>",
+		"<div><div><span></div></div></div>"} {
+		for _, e := range val.ValidateHtmlString(str) {
+			if e.ExpectedTag != "" && e.Note == "expected </"+
+				e.ExpectedTag+"> but found </"+e.ExpectedTag+">" {
+				t.Fatal("expected and found tag are the same", str, e)
+			}
+		}
+	}
+}
+
+func Test_IsValid(t *testing.T) {
+	val := newValidator(ValidTag{Name: "p"}, ValidTag{Name: "button"})
+	val.CheckButtonType = true
+
+	if ok, err := val.IsValid("<p><button></button></p>"); !ok || err != nil {
+		t.Fatal("warnings should not make a document invalid", err)
+	}
+
+	ok, err := val.IsValid("<p>\n<art><kk></p>")
+	if ok || err == nil || err.TagName != "art" || err.TextPos == nil || err.TextPos.Line != 2 {
+		t.Fatal("expected the first error", err)
+	}
+}
+
+func Test_OptionalEndTags(t *testing.T) {
+	val := newValidator(ValidTag{Name: "ul"}, ValidTag{Name: "div"}, ValidTag{Name: "b"},
+		ValidTag{Name: "br", IsSelfClosing: true},
+		ValidTag{Name: "li", AutoClosedBy: []string{"li"}},
+		ValidTag{Name: "p", AutoClosedBy: []string{"p", "div", "ul"}},
+		ValidTag{Name: "tbody", OptionalEndTag: true})
+
+	errors := val.ValidateHtmlString("<ul><li>a<br><li>b</ul><p>one<p>two<div></div><tbody>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<ul><li><b>a<li>b</ul>")
+	if len(errors) != 1 || errors[0].Reason != InvNotProperlyClosed || errors[0].TagName != "b" {
+		t.Fatal("only elements with optional end tags may stay open", errors)
+	}
+
+	errors = val.ValidateHtmlString("<div><li></div>")
+	checkErrors(t, errors)
+}
+
 func BenchmarkValidateHtmlString(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		v.ValidateHtmlString("<b></b>\n<b></b>\n<b kkk='kkk'></b>")
@@ -1191,6 +1191,12 @@ func (z *Tokenizer) GetRawPosition() (int, int) {
 	return z.positionOffset + z.data.start, z.positionOffset + z.data.end
 }
 
+// GetTokenPosition returns the offsets of the whole current token, such as
+// from '<' to '>' for tags, while GetRawPosition only covers its data.
+func (z *Tokenizer) GetTokenPosition() (int, int) {
+	return z.positionOffset + z.raw.start, z.positionOffset + z.raw.end
+}
+
 // SetMaxBuf sets a limit on the amount of data buffered during tokenization.
 // A value of 0 means unlimited.
 func (z *Tokenizer) SetMaxBuf(n int) {
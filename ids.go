@@ -0,0 +1,32 @@
+package htmlcheck
+
+import (
+	"strconv"
+
+	html "github.com/BlackEspresso/htmlcheck/htmlp"
+)
+
+// checkUniqueId reports a value of UniqueAttr, "id" by default, that was
+// already used earlier in the document.
+func (v *Validator) checkUniqueId(s *validation, token html.Token, pos Span) {
+	attr := v.UniqueAttr
+	if attr == "" {
+		attr = "id"
+	}
+	value, ok := attrValue(token, attr)
+	if !ok || value == "" {
+		return
+	}
+	if first, seen := s.uniqueValues[value]; seen {
+		cError := v.report(s, token.Data, attr, value, pos, InvDuplicateId)
+		if cError != nil {
+			cError.Note = "first used on line " + strconv.Itoa(first.Line) +
+				", column " + strconv.Itoa(first.Column)
+		}
+		return
+	}
+	if s.uniqueValues == nil {
+		s.uniqueValues = map[string]*TextPos{}
+	}
+	s.uniqueValues[value] = s.textPos(pos.Start)
+}
@@ -0,0 +1,26 @@
+package htmlcheck
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_CheckUniqueIds(t *testing.T) {
+	val := newValidator(ValidTag{Name: "div", Attrs: []string{"id", "data-key"}})
+	val.CheckUniqueIds = true
+
+	errors := val.ValidateHtmlString(`<div id="a"></div><div id="b"></div><div id=""></div><div id=""></div>`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<div id=\"a\"></div>\n<div id=\"a\"></div>")
+	hasReason(t, errors, InvDuplicateId)
+	if errors[0].TextPos.Line != 2 || !strings.HasSuffix(errors[0].Note, "line 1, column 2") {
+		t.Fatal("expected the second occurrence", errors[0])
+	}
+
+	val.UniqueAttr = "data-key"
+	errors = val.ValidateHtmlString(`<div id="a" data-key="k"></div><div id="a" data-key="k"></div>`)
+	if len(errors) != 1 || errors[0].AttributeName != "data-key" {
+		t.Fatal(errors)
+	}
+}
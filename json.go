@@ -0,0 +1,105 @@
+package htmlcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+var severityNames = map[Severity]string{
+	SeverityError:   "error",
+	SeverityWarning: "warning",
+}
+
+type jsonValidationError struct {
+	Reason         string   `json:"reason"`
+	Severity       string   `json:"severity"`
+	TagName        string   `json:"tagName,omitempty"`
+	AttributeName  string   `json:"attributeName,omitempty"`
+	AttributeValue string   `json:"attributeValue,omitempty"`
+	ExpectedTag    string   `json:"expectedTag,omitempty"`
+	Message        string   `json:"message"`
+	Note           string   `json:"note,omitempty"`
+	Pos            Span     `json:"pos"`
+	TextPos        *TextPos `json:"textPos,omitempty"`
+}
+
+func newJSONValidationError(e *ValidationError) *jsonValidationError {
+	return &jsonValidationError{
+		Reason:         e.Reason.String(),
+		Severity:       severityNames[e.Severity],
+		TagName:        e.TagName,
+		AttributeName:  e.AttributeName,
+		AttributeValue: e.AttributeValue,
+		ExpectedTag:    e.ExpectedTag,
+		Message:        e.Error(),
+		Note:           e.Note,
+		Pos:            e.Pos,
+		TextPos:        e.TextPos,
+	}
+}
+
+// MarshalJSON writes the error with its reason and severity as names and
+// the rendered message.
+func (e *ValidationError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newJSONValidationError(e))
+}
+
+// UnmarshalJSON reads an error written by MarshalJSON. The message is
+// ignored.
+func (e *ValidationError) UnmarshalJSON(data []byte) error {
+	j := jsonValidationError{}
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	reason, ok := reasonByName(j.Reason)
+	if !ok {
+		return fmt.Errorf("unknown reason '%s'", j.Reason)
+	}
+	severity, ok := severityByName(j.Severity)
+	if !ok {
+		return fmt.Errorf("unknown severity '%s'", j.Severity)
+	}
+	*e = ValidationError{TagName: j.TagName, AttributeName: j.AttributeName,
+		AttributeValue: j.AttributeValue, ExpectedTag: j.ExpectedTag,
+		Reason: reason, Pos: j.Pos, TextPos: j.TextPos, Severity: severity,
+		Note: j.Note}
+	return nil
+}
+
+func reasonByName(name string) (ErrorReason, bool) {
+	for reason, n := range reasonNames {
+		if n == name {
+			return reason, true
+		}
+	}
+	return 0, false
+}
+
+func severityByName(name string) (Severity, bool) {
+	for severity, n := range severityNames {
+		if n == name {
+			return severity, true
+		}
+	}
+	return 0, false
+}
+
+// ValidateToJSONL writes every error to w as a single line of JSON as soon as
+// it is found. If w has a Flush method, it is flushed after each line.
+func (v *Validator) ValidateToJSONL(r io.Reader, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(interface{ Flush() error })
+	var writeErr error
+	err := v.validate(r, func(e *ValidationError) bool {
+		writeErr = enc.Encode(newJSONValidationError(e))
+		if writeErr == nil && flusher != nil {
+			writeErr = flusher.Flush()
+		}
+		return writeErr == nil
+	})
+	if writeErr != nil {
+		return writeErr
+	}
+	return err
+}
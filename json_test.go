@@ -0,0 +1,67 @@
+package htmlcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func Test_ValidateToJSONL(t *testing.T) {
+	val := newValidator(ValidTag{Name: "b", Attrs: []string{"id"}})
+
+	out := &bytes.Buffer{}
+	err := val.ValidateToJSONL(strings.NewReader("<b kkk='1'></b>\n<art>"), out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatal("should write one line per error", lines)
+	}
+
+	first := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if first["reason"] != "InvalidAttribute" || first["attributeName"] != "kkk" {
+		t.Fatal(first)
+	}
+	pos := first["pos"].(map[string]interface{})
+	if pos["start"] != 1.0 || pos["end"] != 2.0 {
+		t.Fatal(pos)
+	}
+}
+
+func Test_ValidationErrorJSON(t *testing.T) {
+	val := newValidator(ValidTag{Name: "b", Attrs: []string{"id"}})
+	errors := val.ValidateHtmlString("<b id='1' id='2'></b>\n<art>")
+
+	b, err := json.Marshal(errors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"reason":"DuplicatedAttribute"`) ||
+		!strings.Contains(string(b), `"textPos":{"line":2,"column":2}`) {
+		t.Fatal(string(b))
+	}
+
+	decoded := []*ValidationError{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != len(errors) {
+		t.Fatal(decoded)
+	}
+	for i := range errors {
+		if decoded[i].Error() != errors[i].Error() ||
+			decoded[i].Severity != errors[i].Severity {
+			t.Fatal("round trip changed the error", decoded[i], errors[i])
+		}
+	}
+
+	if InvTag.String() != "InvalidTag" || ErrorReason(-1).String() != "ErrorReason(-1)" {
+		t.Fatal(InvTag.String(), ErrorReason(-1).String())
+	}
+}
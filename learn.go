@@ -0,0 +1,54 @@
+package htmlcheck
+
+import (
+	"io"
+	"sort"
+
+	html "github.com/BlackEspresso/htmlcheck/htmlp"
+)
+
+// LearnTags returns the tags used in the document of r with every attribute
+// they were used with, sorted by name, as a starting point for
+// AddValidTags. HTML5 void elements, like <br>, and tags that are always
+// written self-closing, like <x-icon/>, are marked IsSelfClosing. It
+// returns the first read error of r, if any.
+func LearnTags(r io.Reader) ([]*ValidTag, error) {
+	attrs := map[string]map[string]bool{}
+	opened := map[string]bool{}
+	z := html.NewTokenizer(r)
+	for {
+		tokenType := z.Next()
+		if tokenType == html.ErrorToken {
+			if z.Err() != io.EOF {
+				return nil, z.Err()
+			}
+			break
+		}
+		if tokenType == html.StartTagToken ||
+			tokenType == html.SelfClosingTagToken {
+			token := z.Token()
+			if attrs[token.Data] == nil {
+				attrs[token.Data] = map[string]bool{}
+			}
+			for _, attr := range token.Attr {
+				attrs[token.Data][attr.Key] = true
+			}
+			if tokenType == html.StartTagToken {
+				opened[token.Data] = true
+			}
+		}
+	}
+
+	tags := []*ValidTag{}
+	for name, used := range attrs {
+		tag := &ValidTag{Name: name, Attrs: []string{},
+			IsSelfClosing: html5VoidElements[name] || !opened[name]}
+		for attr := range used {
+			tag.Attrs = append(tag.Attrs, attr)
+		}
+		sort.Strings(tag.Attrs)
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
@@ -0,0 +1,46 @@
+package htmlcheck
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func Test_LearnTags(t *testing.T) {
+	sample := `<div class="a"><p id="x">text<br><img src="i.png"/></p>` +
+		`<p class="b" id="y"></p><ul><li>a<li>b</ul><x-icon/></div>`
+	tags, err := LearnTags(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{}
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	if strings.Join(names, ",") != "br,div,img,li,p,ul,x-icon" {
+		t.Fatal(names)
+	}
+	if strings.Join(tags[4].Attrs, ",") != "class,id" || tags[4].IsSelfClosing {
+		t.Fatal("attributes of repeated tags should be merged", tags[4])
+	}
+	if !tags[0].IsSelfClosing || !tags[2].IsSelfClosing ||
+		!tags[6].IsSelfClosing || tags[1].IsSelfClosing || tags[3].IsSelfClosing {
+		t.Fatal("only void and always self-closing tags are self-closing")
+	}
+
+	val := &Validator{}
+	if err := val.AddValidTags(tags); err != nil {
+		t.Fatal(err)
+	}
+	errors := val.ValidateHtmlString(sample)
+	if len(errors) != 1 || errors[0].TagName != "li" {
+		t.Fatal("the unclosed li should be reported", errors)
+	}
+
+	_, err = LearnTags(iotest.ErrReader(io.ErrUnexpectedEOF))
+	if err != io.ErrUnexpectedEOF {
+		t.Fatal("expected the read error", err)
+	}
+}
@@ -0,0 +1,126 @@
+package htmlcheck
+
+import (
+	"strconv"
+	"strings"
+
+	html "github.com/BlackEspresso/htmlcheck/htmlp"
+)
+
+// container returns the closest open element that is not the current
+// token and not a void element, or "" if there is none.
+func (v *Validator) container(s *validation) string {
+	for i := len(s.parents) - 2; i >= 0; i-- {
+		name := s.parents[i].name
+		if !v.isValidSelfClosingTag(name) {
+			return name
+		}
+	}
+	return ""
+}
+
+// checkSource verifies that a <source> uses the attributes of its parent:
+// src inside <audio> and <video>, srcset inside <picture>.
+func (v *Validator) checkSource(s *validation, token html.Token, pos Span) {
+	wrong := ""
+	switch v.container(s) {
+	case "audio", "video":
+		wrong = "srcset"
+	case "picture":
+		wrong = "src"
+	default:
+		return
+	}
+	if value, ok := attrValue(token, wrong); ok {
+		v.report(s, token.Data, wrong, value, pos, InvBadSourceContext)
+	}
+}
+
+var trackKinds = setOf("subtitles", "captions", "descriptions", "chapters",
+	"metadata")
+
+// checkTrack verifies that a <track> inside <audio> or <video> has a src, a
+// known kind and, for subtitles, a label.
+func (v *Validator) checkTrack(s *validation, token html.Token, pos Span) {
+	switch v.container(s) {
+	case "audio", "video":
+	default:
+		return
+	}
+	if src, ok := attrValue(token, "src"); !ok || strings.TrimSpace(src) == "" {
+		v.report(s, token.Data, "src", src, pos, InvBadTrack)
+	}
+	kind, ok := attrValue(token, "kind")
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if !trackKinds[kind] {
+		cError := v.report(s, token.Data, "kind", kind, pos, InvBadTrack)
+		if cError != nil && !ok {
+			cError.Note = "set kind explicitly, it defaults to subtitles"
+		}
+		return
+	}
+	if kind == "subtitles" && !hasAttr(token, "label") {
+		cError := v.report(s, token.Data, "label", "", pos, InvBadTrack)
+		if cError != nil {
+			cError.Note = "subtitles need a label for the track menu"
+		}
+	}
+}
+
+// checkLoading verifies the loading attribute of an <img> or <iframe> and,
+// with LazyLoadMinArea set, warns about large ones that don't have one.
+func (v *Validator) checkLoading(s *validation, token html.Token, pos Span) {
+	loading, ok := attrValue(token, "loading")
+	if !ok {
+		if v.LazyLoadMinArea > 0 && mediaArea(token) >= v.LazyLoadMinArea {
+			cError := v.report(s, token.Data, "loading", "", pos,
+				InvMissingLoading)
+			if cError != nil {
+				cError.Note = "consider loading=\"lazy\" if it is below the fold"
+			}
+		}
+		return
+	}
+	if v.CheckLoading &&
+		!LoadingValues[strings.ToLower(strings.TrimSpace(loading))] {
+		v.report(s, token.Data, "loading", loading, pos, InvBadLoading)
+	}
+}
+
+// mediaArea returns width times height from the attributes, or 0 if
+// either is missing or not a number.
+func mediaArea(token html.Token) int {
+	width, _ := attrValue(token, "width")
+	height, _ := attrValue(token, "height")
+	w, err := strconv.Atoi(strings.TrimSpace(width))
+	if err != nil {
+		return 0
+	}
+	h, err := strconv.Atoi(strings.TrimSpace(height))
+	if err != nil {
+		return 0
+	}
+	return w * h
+}
+
+// checkInlineData warns about data: URIs in src and href that are longer
+// than MaxInlineDataSize bytes.
+func (v *Validator) checkInlineData(s *validation, token html.Token,
+	pos Span) {
+	for _, attr := range token.Attr {
+		if attr.Key != "src" && attr.Key != "href" {
+			continue
+		}
+		value := strings.TrimSpace(attr.Val)
+		if len(value) <= v.MaxInlineDataSize ||
+			!strings.HasPrefix(strings.ToLower(value), "data:") {
+			continue
+		}
+		cError := v.report(s, token.Data, attr.Key, "", pos,
+			InvLargeInlineData)
+		if cError != nil {
+			cError.Note = strconv.Itoa(len(value)) + " bytes inline, " +
+				"serve it as a separate file so it can be cached"
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package htmlcheck
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_SourceContext(t *testing.T) {
+	val := newValidator(ValidTag{Name: "video"}, ValidTag{Name: "audio"},
+		ValidTag{Name: "picture"}, ValidTag{Name: "img", Attrs: []string{"src"}, IsSelfClosing: true},
+		ValidTag{Name: "source", Attrs: []string{"src", "srcset", "type", "media"}, IsSelfClosing: true})
+	val.CheckSourceContext = true
+
+	errors := val.ValidateHtmlString(`<video><source src="a.mp4" type="video/mp4">` +
+		`<source src="a.webm" type="video/webm"></video>` +
+		`<picture><source srcset="a.avif" media="(min-width: 600px)"><img src="a.png"></picture>`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<video><source srcset="a.mp4"></video>`)
+	hasReason(t, errors, InvBadSourceContext)
+
+	errors = val.ValidateHtmlString(`<picture><source src="a.avif"><img src="a.png"></picture>`)
+	hasReason(t, errors, InvBadSourceContext)
+}
+
+func Test_Track(t *testing.T) {
+	val := newValidator(ValidTag{Name: "video"},
+		ValidTag{Name: "track", Attrs: []string{"src", "kind", "label", "srclang"}, IsSelfClosing: true})
+	val.CheckTracks = true
+
+	errors := val.ValidateHtmlString(`<video><track src="en.vtt" kind="subtitles" label="English" srclang="en">` +
+		`<track src="ch.vtt" kind="chapters"></video>`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<video><track kind="captions"></video>`)
+	hasReason(t, errors, InvBadTrack)
+
+	errors = val.ValidateHtmlString(`<video><track src="en.vtt" kind="subtitles"></video>`)
+	hasReason(t, errors, InvBadTrack)
+}
+
+func Test_Loading(t *testing.T) {
+	val := newValidator(ValidTag{Name: "img", Attrs: []string{"src", "loading", "width", "height"},
+		IsSelfClosing: true})
+	val.CheckLoading = true
+
+	errors := val.ValidateHtmlString(`<img src="a.png" loading="lazy"><img src="b.png" loading="eager">` +
+		`<img src="c.png" width="1200" height="800">`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<img src="a.png" loading="later">`)
+	hasReason(t, errors, InvBadLoading)
+
+	val.LazyLoadMinArea = 500 * 500
+	errors = val.ValidateHtmlString(`<img src="icon.png" width="32" height="32">`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<img src="c.png" width="1200" height="800">`)
+	hasReason(t, errors, InvMissingLoading)
+}
+
+func Test_LargeInlineData(t *testing.T) {
+	val := newValidator(ValidTag{Name: "img", Attrs: []string{"src"}, IsSelfClosing: true})
+	val.MaxInlineDataSize = 1024
+
+	errors := val.ValidateHtmlString(`<img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=">` +
+		`<img src="/` + strings.Repeat("a", 2048) + `.png">`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<img src="data:image/png;base64,` +
+		strings.Repeat("A", 2048) + `">`)
+	hasReason(t, errors, InvLargeInlineData)
+}
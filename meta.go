@@ -0,0 +1,103 @@
+package htmlcheck
+
+import (
+	"regexp"
+	"strings"
+
+	html "github.com/BlackEspresso/htmlcheck/htmlp"
+)
+
+var reRefresh = regexp.MustCompile(`(?i)^\s*\d+(\.\d*)?\s*([;,]\s*(url\s*=\s*)?\S.*)?$`)
+
+// checkTitle counts the titles of the document and reports a title
+// without meaningful content when it is closed.
+func (v *Validator) checkTitle(s *validation, token html.Token, pos Span) {
+	switch token.Type {
+	case html.StartTagToken:
+		s.titleCount++
+		s.titleText = ""
+		s.titlePos = pos
+		s.titleTextPos = s.textPos(pos.Start)
+		if s.titleCount > 1 {
+			v.report(s, token.Data, "", "", pos, InvMultipleTitles)
+		}
+	case html.SelfClosingTagToken:
+		s.titleCount++
+		v.report(s, token.Data, "", "", pos, InvEmptyTitle)
+	case html.EndTagToken:
+		if s.parent() == "title" && !v.IsMeaningfulContent(s.titleText) {
+			v.reportAt(s, token.Data, "", "", s.titlePos, s.titleTextPos,
+				InvEmptyTitle)
+		}
+	}
+}
+
+// checkHttpEquiv verifies that http-equiv is a known pragma and that a
+// refresh has a valid content.
+func (v *Validator) checkHttpEquiv(s *validation, token html.Token, pos Span) {
+	value, ok := attrValue(token, "http-equiv")
+	if !ok {
+		return
+	}
+	pragma := strings.ToLower(strings.TrimSpace(value))
+	if !HttpEquivValues[pragma] {
+		v.report(s, token.Data, "http-equiv", value, pos, InvBadHttpEquiv)
+		return
+	}
+	content, _ := attrValue(token, "content")
+	if pragma == "refresh" && !reRefresh.MatchString(content) {
+		cError := v.report(s, token.Data, "content", content, pos,
+			InvBadHttpEquiv)
+		if cError != nil {
+			cError.Note = "refresh content should be a number of seconds " +
+				"optionally followed by ;url=..."
+		}
+	}
+}
+
+// checkViewport verifies that a <meta name="viewport"> is well formed and
+// sets width=device-width.
+func (v *Validator) checkViewport(s *validation, token html.Token, pos Span) {
+	name, _ := attrValue(token, "name")
+	if !strings.EqualFold(strings.TrimSpace(name), "viewport") {
+		return
+	}
+
+	content, _ := attrValue(token, "content")
+	note := ""
+	values, ok := parseViewport(content)
+	if !ok {
+		note = "viewport content should be a comma separated list of key=value pairs"
+	} else if values["width"] != "device-width" {
+		note = "viewport content should include width=device-width"
+	}
+	if note == "" {
+		return
+	}
+	cError := v.report(s, token.Data, "content", content, pos, InvBadViewport)
+	if cError != nil {
+		cError.Note = note
+	}
+}
+
+func parseViewport(content string) (map[string]string, bool) {
+	values := map[string]string{}
+	if strings.TrimSpace(content) == "" {
+		return values, false
+	}
+	for _, entry := range strings.FieldsFunc(content, func(r rune) bool {
+		return r == ',' || r == ';'
+	}) {
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 {
+			return values, false
+		}
+		key := strings.ToLower(strings.TrimSpace(kv[0]))
+		val := strings.ToLower(strings.TrimSpace(kv[1]))
+		if key == "" || val == "" {
+			return values, false
+		}
+		values[key] = val
+	}
+	return values, true
+}
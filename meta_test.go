@@ -0,0 +1,69 @@
+package htmlcheck
+
+import (
+	"testing"
+)
+
+func newMetaValidator() *Validator {
+	return newValidator(ValidTag{Name: "meta",
+		Attrs:         []string{"name", "content", "charset", "http-equiv"},
+		IsSelfClosing: true})
+}
+
+func Test_Viewport(t *testing.T) {
+	val := newMetaValidator()
+	val.CheckViewport = true
+
+	errors := val.ValidateHtmlString(`<meta name="viewport" ` +
+		`content="width=device-width, initial-scale=1">`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<meta name="description" content="x">`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<meta name="viewport" content="initial-scale=1">`)
+	hasReason(t, errors, InvBadViewport)
+
+	errors = val.ValidateHtmlString(`<meta name="viewport" content="width device-width">`)
+	hasReason(t, errors, InvBadViewport)
+
+	errors = val.ValidateHtmlString(`<meta name="viewport">`)
+	hasReason(t, errors, InvBadViewport)
+}
+
+func Test_HttpEquiv(t *testing.T) {
+	val := newMetaValidator()
+	val.CheckHttpEquiv = true
+
+	errors := val.ValidateHtmlString(`<meta http-equiv="X-UA-Compatible" content="IE=edge">` +
+		`<meta http-equiv="refresh" content="5; url=https://example.com/">` +
+		`<meta http-equiv="refresh" content="30">`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<meta http-equiv="expires" content="0">`)
+	hasReason(t, errors, InvBadHttpEquiv)
+
+	errors = val.ValidateHtmlString(`<meta http-equiv="refresh" content="soon">`)
+	hasReason(t, errors, InvBadHttpEquiv)
+}
+
+func Test_Title(t *testing.T) {
+	val := newValidator(ValidTag{Name: "head"}, ValidTag{Name: "title"})
+	val.CheckTitle = true
+
+	errors := val.ValidateHtmlString("<head></head>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<head><title>Home</title></head>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<head><title>Home</title><title>Again</title></head>")
+	hasReason(t, errors, InvMultipleTitles)
+
+	errors = val.ValidateHtmlString("<head><title> </title></head>")
+	hasReason(t, errors, InvEmptyTitle)
+
+	val.RequireTitle = true
+	errors = val.ValidateHtmlString("<head></head>")
+	hasReason(t, errors, InvMissingTitle)
+}
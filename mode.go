@@ -0,0 +1,72 @@
+package htmlcheck
+
+// Mode selects whether a Validator checks a complete page or a fragment
+// like a template partial.
+type Mode int
+
+const (
+	// ModeFragment doesn't require any document structure.
+	ModeFragment Mode = iota
+	// ModeDocument requires a doctype and html, head and body elements.
+	// Since their end tags are optional, they may be left open.
+	ModeDocument
+)
+
+var documentElements = []string{"html", "head", "body"}
+
+// checkStructure reports elements outside the html element, head and body
+// elements that are not children of html and a head after the body. It
+// remembers which of html, head and body were seen.
+func (v *Validator) checkStructure(s *validation, tagName string, pos Span) {
+	if len(s.parents) == 0 && tagName != "html" {
+		cError := v.report(s, tagName, "", "", pos, InvDocumentStructure)
+		if cError != nil {
+			cError.Note = "outside the html element"
+		}
+	}
+	if tagName == "head" || tagName == "body" {
+		v.checkHeadAndBody(s, tagName, pos)
+	}
+	if indexOf(documentElements, tagName) > -1 {
+		if s.documentElements == nil {
+			s.documentElements = map[string]bool{}
+		}
+		s.documentElements[tagName] = true
+	}
+}
+
+// checkHeadAndBody reports a head or body that is not a child of html and a
+// head that comes after the body. A body may follow a head whose optional
+// end tag was left out.
+func (v *Validator) checkHeadAndBody(s *validation, tagName string,
+	pos Span) {
+	parent := s.parent()
+	if tagName == "body" && parent == "head" && len(s.parents) > 1 {
+		parent = s.parents[len(s.parents)-2].name
+	}
+	if len(s.parents) > 0 && parent != "html" {
+		cError := v.report(s, tagName, "", "", pos, InvDocumentStructure)
+		if cError != nil {
+			cError.Note = "'" + tagName + "' must be a child of 'html'"
+		}
+	}
+	if tagName == "head" && s.documentElements["body"] {
+		cError := v.report(s, tagName, "", "", pos, InvDocumentStructure)
+		if cError != nil {
+			cError.Note = "'head' must come before 'body'"
+		}
+	}
+}
+
+// checkDocumentElements reports html, head and body elements that are
+// missing at the end of the document.
+func (v *Validator) checkDocumentElements(s *validation) {
+	for _, name := range documentElements {
+		if !s.documentElements[name] {
+			cError := v.report(s, name, "", "", Span{}, InvDocumentStructure)
+			if cError != nil {
+				cError.Note = "missing"
+			}
+		}
+	}
+}
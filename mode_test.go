@@ -0,0 +1,46 @@
+package htmlcheck
+
+import (
+	"testing"
+)
+
+func Test_Mode(t *testing.T) {
+	val := newValidator(ValidTag{Name: "html"}, ValidTag{Name: "head"},
+		ValidTag{Name: "body"}, ValidTag{Name: "title"}, ValidTag{Name: "li"})
+
+	errors := val.ValidateHtmlString("<li>item</li>")
+	checkErrors(t, errors)
+
+	val.Mode = ModeDocument
+	errors = val.ValidateHtmlString("<!DOCTYPE html><html><head><title>t</title></head><body><li>item</li>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<li>item</li>")
+	hasReason(t, errors, InvMissingDoctype)
+	hasReason(t, errors, InvDocumentStructure)
+	if len(errors) != 5 {
+		t.Fatal("expected the doctype, the stray li and html, head and body", errors)
+	}
+}
+
+func Test_HeadAndBodyOrder(t *testing.T) {
+	val := newValidator(ValidTag{Name: "html"}, ValidTag{Name: "head"},
+		ValidTag{Name: "body"}, ValidTag{Name: "title"}, ValidTag{Name: "div"})
+	val.Mode = ModeDocument
+
+	errors := val.ValidateHtmlString("<!DOCTYPE html><html><head><title>t</title><body></body></html>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<!DOCTYPE html><html><body></body><head></head></html>")
+	if len(errors) != 1 || errors[0].Reason != InvDocumentStructure ||
+		errors[0].TagName != "head" ||
+		errors[0].Note != "'head' must come before 'body'" {
+		t.Fatal("expected the head after the body", errors)
+	}
+
+	errors = val.ValidateHtmlString("<!DOCTYPE html><html><head></head><div><body></body></div></html>")
+	if len(errors) != 1 || errors[0].TagName != "body" ||
+		errors[0].Note != "'body' must be a child of 'html'" {
+		t.Fatal("expected the misplaced body", errors)
+	}
+}
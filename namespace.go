@@ -0,0 +1,89 @@
+package htmlcheck
+
+import (
+	"strings"
+
+	html "github.com/BlackEspresso/htmlcheck/htmlp"
+)
+
+// AddNamespace adds tags that are only valid inside a root element, like
+// the SVG elements inside <svg>. Inside root, tag names are matched
+// case-sensitively and reported as root:name, attribute names are matched
+// case-insensitively and self-closing syntax closes an element. The root
+// itself has to be added as a regular tag. AllowedParents may name root or
+// other tags of the namespace.
+func (v *Validator) AddNamespace(root string, tags []*ValidTag) error {
+	qualified := make([]*ValidTag, len(tags))
+	for i, tag := range tags {
+		t := *tag
+		t.Name = root + ":" + tag.Name
+		t.Attrs = lowerAll(tag.Attrs)
+		t.RecommendedAttrs = lowerAll(tag.RecommendedAttrs)
+		t.RequiredAttrs = lowerAll(tag.RequiredAttrs)
+		t.ValueAttrs = lowerAll(tag.ValueAttrs)
+		t.AllowedParents = nil
+		for _, parent := range tag.AllowedParents {
+			if parent != root {
+				parent = root + ":" + parent
+			}
+			t.AllowedParents = append(t.AllowedParents, parent)
+		}
+		qualified[i] = &t
+	}
+	if err := v.AddValidTags(qualified); err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.namespaces == nil {
+		v.namespaces = map[string]bool{}
+	}
+	v.namespaces[root] = true
+	return nil
+}
+
+func lowerAll(names []string) []string {
+	if names == nil {
+		return nil
+	}
+	lower := make([]string, len(names))
+	for i, name := range names {
+		lower[i] = strings.ToLower(name)
+	}
+	return lower
+}
+
+// namespace returns the root of the namespace the next element is in, or
+// "" outside of namespaces.
+func (s *validation) namespace() string {
+	if len(s.parents) == 0 {
+		return ""
+	}
+	return s.parents[len(s.parents)-1].ns
+}
+
+// qualify returns the name of a tag inside the current namespace. End tags
+// that don't close an element of the namespace, like the end tag of the
+// root, keep their HTML name.
+func (s *validation) qualify(token html.Token, rawName string) string {
+	name := s.namespace() + ":" + rawName
+	if token.Type == html.EndTagToken && s.indexOf(name) == -1 {
+		return token.Data
+	}
+	return name
+}
+
+// rawTagName returns the name of a start or end tag as written.
+func rawTagName(raw []byte) string {
+	start := 1
+	if start < len(raw) && raw[start] == '/' {
+		start++
+	}
+	end := start
+	for end < len(raw) && !isSpace(raw[end]) && raw[end] != '/' &&
+		raw[end] != '>' {
+		end++
+	}
+	return string(raw[start:end])
+}
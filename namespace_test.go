@@ -0,0 +1,34 @@
+package htmlcheck
+
+import (
+	"testing"
+)
+
+func Test_Namespace(t *testing.T) {
+	val := newValidator(ValidTag{Name: "div"}, ValidTag{Name: "path"},
+		ValidTag{Name: "svg", Attrs: []string{"viewbox"}})
+	err := val.AddNamespace("svg", []*ValidTag{
+		{Name: "path", Attrs: []string{"d"}},
+		{Name: "linearGradient", Attrs: []string{"id"}},
+		{Name: "stop", Attrs: []string{"offset"}, AllowedParents: []string{"linearGradient"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errors := val.ValidateHtmlString(`<div><svg viewBox="0 0 1 1"><linearGradient id="g">` +
+		`<stop offset="0"/></linearGradient><path d="M0 0"/></svg><path></path></div>`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<svg><lineargradient></lineargradient></svg>`)
+	hasReason(t, errors, InvTag)
+	if errors[0].TagName != "svg:lineargradient" {
+		t.Fatal(errors[0])
+	}
+
+	errors = val.ValidateHtmlString(`<div><linearGradient></linearGradient></div>`)
+	hasReason(t, errors, InvTag)
+
+	errors = val.ValidateHtmlString(`<svg><stop offset="1"/></svg>`)
+	hasReason(t, errors, InvIllegalNesting)
+}
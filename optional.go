@@ -0,0 +1,44 @@
+package htmlcheck
+
+// hasOptionalEndTag reports whether the element may be closed without its
+// end tag. In ModeDocument that includes html, head and body.
+func (v *Validator) hasOptionalEndTag(name string) bool {
+	if v.Mode == ModeDocument && indexOf(documentElements, name) > -1 {
+		return true
+	}
+	tag := v.validTags[name]
+	return tag != nil && (tag.OptionalEndTag || len(tag.AutoClosedBy) > 0)
+}
+
+// autoClose closes the innermost open elements that list tagName in their
+// AutoClosedBy, like an open li before another li.
+func (v *Validator) autoClose(s *validation, tagName string) {
+	for {
+		i := len(s.parents) - 1
+		for i >= 0 && v.isValidSelfClosingTag(s.parents[i].name) {
+			i--
+		}
+		if i < 0 {
+			return
+		}
+		tag := v.validTags[s.parents[i].name]
+		if tag == nil || indexOf(tag.AutoClosedBy, tagName) == -1 {
+			return
+		}
+		closed := s.parents[i:]
+		s.parents = s.parents[:i]
+		v.closeElements(s, closed)
+	}
+}
+
+// unclosedElement returns the innermost element of open that needed an end
+// tag, skipping void elements and those with optional end tags.
+func (v *Validator) unclosedElement(open []element) (element, bool) {
+	for i := len(open) - 1; i >= 0; i-- {
+		name := open[i].name
+		if !v.isValidSelfClosingTag(name) && !v.hasOptionalEndTag(name) {
+			return open[i], true
+		}
+	}
+	return element{}, false
+}
@@ -0,0 +1,21 @@
+package htmlcheck
+
+import (
+	"testing"
+)
+
+func Test_NestedOptionalEndTags(t *testing.T) {
+	val := HTML5Validator()
+
+	errors := val.ValidateHtmlString("<ul><li>x<ul><li>y</ul></li></ul>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<div><p>a<div><p>b</div><p>c</div>")
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString("<div><b><div>a</b></div>")
+	if len(errors) != 1 || errors[0].Reason != InvNotProperlyClosed ||
+		errors[0].TagName != "div" || errors[0].Pos.Start != 8 {
+		t.Fatal("the inner div should be reported", errors)
+	}
+}
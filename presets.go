@@ -0,0 +1,86 @@
+package htmlcheck
+
+// newPreset panics if the tags can't be added, the presets are fixed so
+// that is a bug in the preset.
+func newPreset(globalAttrs []string, tags []ValidTag) *Validator {
+	v := &Validator{}
+	all := []*ValidTag{{Name: "", Attrs: globalAttrs}}
+	for i := range tags {
+		all = append(all, &tags[i])
+	}
+	if err := v.AddValidTags(all); err != nil {
+		panic("htmlcheck: invalid preset: " + err.Error())
+	}
+	return v
+}
+
+func containers(names ...string) []ValidTag {
+	tags := make([]ValidTag, len(names))
+	for i, name := range names {
+		tags[i] = ValidTag{Name: name}
+	}
+	return tags
+}
+
+// EmailHTMLValidator returns a validator for the HTML subset email clients
+// render reliably: table based layout, inline styles and no scripts, forms,
+// frames or media elements.
+func EmailHTMLValidator() *Validator {
+	tags := containers("html", "head", "body", "title", "style", "div",
+		"span", "p", "b", "i", "u", "em", "strong", "small", "sub", "sup",
+		"h1", "h2", "h3", "h4", "h5", "h6", "ul", "ol", "li", "blockquote",
+		"center", "font", "thead", "tbody", "tfoot", "tr")
+	tags = append(tags,
+		ValidTag{Name: "a", Attrs: []string{"href", "target", "name"}},
+		ValidTag{Name: "img", Attrs: []string{"src", "width", "height",
+			"border"}, RecommendedAttrs: []string{"alt"}, IsSelfClosing: true},
+		ValidTag{Name: "br", IsSelfClosing: true},
+		ValidTag{Name: "hr", IsSelfClosing: true},
+		ValidTag{Name: "meta", Attrs: []string{"charset", "name", "content",
+			"http-equiv"}, IsSelfClosing: true},
+		ValidTag{Name: "table", Attrs: []string{"width", "border",
+			"cellpadding", "cellspacing", "role"}},
+		ValidTag{Name: "td", Attrs: []string{"width", "height", "colspan",
+			"rowspan", "valign"}},
+		ValidTag{Name: "th", Attrs: []string{"width", "height", "colspan",
+			"rowspan", "valign", "scope"}})
+	return newPreset([]string{"style", "class", "id", "align", "bgcolor",
+		"dir", "lang", "title"}, tags)
+}
+
+// AMPValidator returns a validator for AMP pages: media and frames must use
+// their amp-* components, inline style attributes are not allowed and
+// scripts only accept the attributes used to load the AMP runtime and
+// extensions.
+func AMPValidator() *Validator {
+	tags := containers("head", "body", "title", "div", "span", "p",
+		"b", "i", "u", "em", "strong", "small", "sub", "sup", "code", "pre",
+		"h1", "h2", "h3", "h4", "h5", "h6", "ul", "ol", "li", "dl", "dt",
+		"dd", "blockquote", "header", "footer", "main", "nav", "section",
+		"article", "aside", "figure", "figcaption", "table", "thead",
+		"tbody", "tfoot", "tr", "td", "th", "button", "label", "noscript",
+		"template")
+	tags = append(tags,
+		ValidTag{Name: "html", Attrs: []string{"amp", "⚡"}},
+		ValidTag{Name: "a", Attrs: []string{"href", "target", "rel"}},
+		ValidTag{Name: "meta", Attrs: []string{"charset", "name",
+			"content"}, IsSelfClosing: true},
+		ValidTag{Name: "link", Attrs: []string{"rel", "href"},
+			IsSelfClosing: true},
+		ValidTag{Name: "style", Attrs: []string{"amp-custom",
+			"amp-boilerplate"}},
+		ValidTag{Name: "script", Attrs: []string{"async", "src",
+			"custom-element", "custom-template", "type", "nomodule"}},
+		ValidTag{Name: "br", IsSelfClosing: true},
+		ValidTag{Name: "hr", IsSelfClosing: true})
+	for _, name := range []string{"amp-img", "amp-video", "amp-audio",
+		"amp-iframe", "amp-carousel", "amp-ad", "amp-analytics",
+		"amp-list", "amp-accordion", "amp-sidebar", "amp-youtube"} {
+		tags = append(tags, ValidTag{Name: name, Attrs: []string{"src",
+			"srcset", "sizes", "width", "height", "layout", "alt",
+			"type", "controls", "autoplay", "loop", "sandbox"},
+			AttrStartsWith: "data-"})
+	}
+	return newPreset([]string{"class", "id", "lang", "dir", "title",
+		"hidden", "on", "role", "tabindex"}, tags)
+}
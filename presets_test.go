@@ -0,0 +1,33 @@
+package htmlcheck
+
+import (
+	"testing"
+)
+
+func Test_EmailHTMLValidator(t *testing.T) {
+	val := EmailHTMLValidator()
+
+	errors := val.ValidateHtmlString(`<table width="600" cellpadding="0"><tr>` +
+		`<td style="color:#333"><a href="https://example.com">Hi</a>` +
+		`<img src="logo.png" alt="Logo"></td></tr></table>`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<div><script>track()</script></div>`)
+	hasReason(t, errors, InvTag)
+}
+
+func Test_AMPValidator(t *testing.T) {
+	val := AMPValidator()
+
+	errors := val.ValidateHtmlString(`<html amp><head>` +
+		`<script async src="https://cdn.ampproject.org/v0.js"></script></head>` +
+		`<body><amp-img src="a.png" width="100" height="100" layout="responsive">` +
+		`</amp-img></body></html>`)
+	checkErrors(t, errors)
+
+	errors = val.ValidateHtmlString(`<body><img src="a.png"></body>`)
+	hasReason(t, errors, InvTag)
+
+	errors = val.ValidateHtmlString(`<div style="color:red"></div>`)
+	hasReason(t, errors, InvAttribute)
+}
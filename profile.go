@@ -0,0 +1,53 @@
+package htmlcheck
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Timings is the time spent in each phase of validation, summed over all
+// validations run with Profile enabled since the last ResetTimings.
+type Timings struct {
+	Tokenize  time.Duration
+	Rules     time.Duration
+	Positions time.Duration
+}
+
+type timingCounters struct {
+	tokenize  atomic.Int64
+	rules     atomic.Int64
+	positions atomic.Int64
+}
+
+// lap adds the time since the previous lap to d. It does nothing unless
+// profiling is enabled, so the unprofiled path never reads the clock.
+func (s *validation) lap(d *time.Duration) {
+	if !s.profile {
+		return
+	}
+	now := time.Now()
+	*d += now.Sub(s.mark)
+	s.mark = now
+}
+
+func (v *Validator) addTimings(t *Timings) {
+	v.timings.tokenize.Add(int64(t.Tokenize))
+	v.timings.rules.Add(int64(t.Rules))
+	v.timings.positions.Add(int64(t.Positions))
+}
+
+// Timings returns the time spent by all profiled validations so far.
+func (v *Validator) Timings() Timings {
+	return Timings{
+		Tokenize:  time.Duration(v.timings.tokenize.Load()),
+		Rules:     time.Duration(v.timings.rules.Load()),
+		Positions: time.Duration(v.timings.positions.Load()),
+	}
+}
+
+// ResetTimings sets the collected timings back to zero.
+func (v *Validator) ResetTimings() {
+	v.timings.tokenize.Store(0)
+	v.timings.rules.Store(0)
+	v.timings.positions.Store(0)
+}
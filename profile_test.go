@@ -0,0 +1,28 @@
+package htmlcheck
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_Profile(t *testing.T) {
+	val := newValidator(ValidTag{Name: "b", Attrs: []string{"id"}})
+	doc := strings.Repeat("<b id='x'>text</b>\n", 1000)
+
+	val.ValidateHtmlString(doc)
+	if val.Timings() != (Timings{}) {
+		t.Fatal("should not record timings unless Profile is set")
+	}
+
+	val.Profile = true
+	val.ValidateHtmlString(doc)
+	timings := val.Timings()
+	if timings.Tokenize <= 0 || timings.Rules <= 0 {
+		t.Fatal("should record timings", timings)
+	}
+
+	val.ResetTimings()
+	if val.Timings() != (Timings{}) {
+		t.Fatal("should reset timings")
+	}
+}
@@ -0,0 +1,123 @@
+package htmlcheck
+
+import (
+	"strings"
+)
+
+// rawAttr is an attribute exactly as written in the source, before the
+// tokenizer lower-cases the key and unescapes the value.
+type rawAttr struct {
+	Key        string
+	Val        string
+	Quote      byte // '"', '\'' or 0 for unquoted values
+	HasValue   bool
+	Terminated bool // false if a quoted value is never closed
+	Next       byte // byte following the closing quote, 0 if none
+}
+
+func isSpace(c byte) bool {
+	switch c {
+	case ' ', '\n', '\r', '\t', '\f':
+		return true
+	}
+	return false
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// parseRawAttrs splits the raw bytes of a start tag like `<a href="x">` into
+// its attributes, following the same rules as the tokenizer.
+func parseRawAttrs(raw []byte) []rawAttr {
+	attrs := []rawAttr{}
+	i := 1
+	for i < len(raw) && !isSpace(raw[i]) && raw[i] != '/' && raw[i] != '>' {
+		i++
+	}
+	for i < len(raw) {
+		for i < len(raw) && (isSpace(raw[i]) || raw[i] == '/') {
+			i++
+		}
+		if i >= len(raw) || raw[i] == '>' {
+			break
+		}
+
+		start := i
+		i++
+		for i < len(raw) && !isSpace(raw[i]) && raw[i] != '/' &&
+			raw[i] != '=' && raw[i] != '>' {
+			i++
+		}
+		attr := rawAttr{Key: string(raw[start:i]), Terminated: true}
+
+		j := i
+		for j < len(raw) && isSpace(raw[j]) {
+			j++
+		}
+		if j >= len(raw) || raw[j] != '=' {
+			attrs = append(attrs, attr)
+			continue
+		}
+		j++
+		for j < len(raw) && isSpace(raw[j]) {
+			j++
+		}
+		if j >= len(raw) || raw[j] == '>' {
+			attrs = append(attrs, attr)
+			i = j
+			continue
+		}
+
+		attr.HasValue = true
+		if raw[j] == '"' || raw[j] == '\'' {
+			attr.Quote = raw[j]
+			end := strings.IndexByte(string(raw[j+1:]), attr.Quote)
+			if end < 0 {
+				attr.Val = string(raw[j+1:])
+				attr.Terminated = false
+				i = len(raw)
+			} else {
+				attr.Val = string(raw[j+1 : j+1+end])
+				i = j + 1 + end + 1
+				if i < len(raw) {
+					attr.Next = raw[i]
+				}
+			}
+		} else {
+			start = j
+			for j < len(raw) && !isSpace(raw[j]) && raw[j] != '>' {
+				j++
+			}
+			attr.Val = string(raw[start:j])
+			i = j
+		}
+		attrs = append(attrs, attr)
+	}
+	return attrs
+}
+
+// hasUnescaped reports whether the value contains a raw '<' or '>', or a
+// quote character that should have been written as an entity.
+func (a *rawAttr) hasUnescaped() bool {
+	if strings.ContainsAny(a.Val, "<>") {
+		return true
+	}
+	if a.Quote == 0 {
+		return strings.ContainsAny(a.Val, "\"'`")
+	}
+	return a.Next != 0 && !isSpace(a.Next) && a.Next != '/' && a.Next != '>'
+}
+
+// isMalformed reports whether a quoted value is never closed or its closing
+// quote runs straight into the following text, which usually means the
+// opening and closing quotes don't match.
+func (a *rawAttr) isMalformed() bool {
+	if a.Quote == 0 {
+		return false
+	}
+	if !a.Terminated {
+		return true
+	}
+	return a.Next != 0 && !isSpace(a.Next) && a.Next != '/' && a.Next != '>'
+}
@@ -0,0 +1,84 @@
+package htmlcheck
+
+import (
+	"bufio"
+	"io"
+
+	html "github.com/BlackEspresso/htmlcheck/htmlp"
+)
+
+// rawTextTags are removed by Sanitize together with their contents, even
+// when they are valid tags.
+var rawTextTags = setOf("script", "style")
+
+// Sanitize copies r to w, leaving out tags that are not valid and
+// attributes that are not valid on their tag. The text inside a removed
+// tag is kept, escaped. Whatever the configuration, script and style
+// elements are removed with their contents, and so are event handler
+// attributes and URLs with a scheme that is not allowed. Comments rejected
+// by AllowedComment or DisallowedComment are removed.
+func (v *Validator) Sanitize(r io.Reader, w io.Writer) error {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	z := html.NewTokenizer(r)
+	inRawText := false
+	for {
+		tokenType := z.Next()
+		if tokenType == html.ErrorToken {
+			if z.Err() != io.EOF {
+				return z.Err()
+			}
+			return bw.Flush()
+		}
+		raw := string(z.Raw())
+		token := z.Token()
+
+		switch tokenType {
+		case html.TextToken:
+			if !inRawText {
+				bw.WriteString(html.EscapeString(token.Data))
+			}
+		case html.CommentToken:
+			if v.isAllowedComment(token.Data) {
+				bw.WriteString(raw)
+			}
+		case html.DoctypeToken:
+			bw.WriteString(raw)
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if rawTextTags[token.Data] {
+				inRawText = tokenType == html.StartTagToken
+				continue
+			}
+			if v.isValidTag(token.Data) {
+				v.writeSanitizedTag(bw, token)
+			}
+		case html.EndTagToken:
+			if rawTextTags[token.Data] {
+				inRawText = false
+			} else if v.isValidTag(token.Data) {
+				bw.WriteString("</" + token.Data + ">")
+			}
+		}
+	}
+}
+
+func (v *Validator) writeSanitizedTag(w *bufio.Writer, token html.Token) {
+	w.WriteString("<" + token.Data)
+	for _, attr := range token.Attr {
+		if !v.isValidAttribute(token.Data, attr.Key) ||
+			isEventHandler(attr.Key) {
+			continue
+		}
+		if _, unsafe := v.isUnsafeURL(attr.Key, attr.Val); unsafe {
+			continue
+		}
+		w.WriteString(" " + attr.Key + "=\"" + html.EscapeString(attr.Val) +
+			"\"")
+	}
+	if token.Type == html.SelfClosingTagToken {
+		w.WriteString("/")
+	}
+	w.WriteString(">")
+}
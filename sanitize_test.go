@@ -0,0 +1,60 @@
+package htmlcheck
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_Sanitize(t *testing.T) {
+	val := newValidator(ValidTag{Name: "p", Attrs: []string{"class"}},
+		ValidTag{Name: "br", IsSelfClosing: true}, ValidTag{Name: "style"})
+
+	tests := map[string]string{
+		`<p class="a" onclick="x()">hi<br/></p>`:           `<p class="a">hi<br/></p>`,
+		`<p><font color="red">kept <b>text</b></font></p>`: `<p>kept text</p>`,
+		`<script>alert("<b>")</script>`:                    ``,
+		`<style>p > b { color: red }</style>`:              ``,
+		`<p title='"'>&lt;x&gt;</p><!-- c -->`:             `<p>&lt;x&gt;</p><!-- c -->`,
+	}
+	for input, expected := range tests {
+		out := &bytes.Buffer{}
+		if err := val.Sanitize(strings.NewReader(input), out); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != expected {
+			t.Fatal(input, out.String())
+		}
+	}
+}
+
+func Test_SanitizeEventHandlers(t *testing.T) {
+	val := newValidator(ValidTag{Name: "p", AttrRegEx: ".*"})
+	val.BlockEventHandlers = true
+
+	out := &bytes.Buffer{}
+	val.Sanitize(strings.NewReader(`<p id="a" onclick="x()"></p>`), out)
+	if out.String() != `<p id="a"></p>` {
+		t.Fatal(out.String())
+	}
+}
+
+func Test_SanitizeHTML5(t *testing.T) {
+	val := HTML5Validator()
+
+	tests := map[string]string{
+		`<div><sCrIpT>alert(1)</sCrIpT><b onclick="x()">t</b></div>`: `<div><b>t</b></div>`,
+		`<style>b { color: red }</style><p>a</p>`:                    `<p>a</p>`,
+		`<a href="javascript:alert(1)" title="t">a</a>`:              `<a title="t">a</a>`,
+		`<img src=x onerror="alert(1)">`:                             `<img src="x">`,
+	}
+	for input, expected := range tests {
+		out := &bytes.Buffer{}
+		if err := val.Sanitize(strings.NewReader(input), out); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != expected {
+			t.Fatal(input, out.String())
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package htmlcheck
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"sort"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+	CharOffset  int `json:"charOffset"`
+	CharLength  int `json:"charLength"`
+}
+
+// WriteSARIF writes the errors of each file, as returned by ValidateFiles,
+// to w as a SARIF 2.1.0 log. Every error reason used becomes a rule.
+func WriteSARIF(w io.Writer, results map[string][]*ValidationError) error {
+	paths := make([]string, 0, len(results))
+	for path := range results {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	driver := sarifDriver{
+		Name:           "htmlcheck",
+		InformationURI: "https://github.com/BlackEspresso/htmlcheck",
+		Rules:          []sarifRule{},
+	}
+	ruleIndex := map[ErrorReason]int{}
+	run := sarifRun{Results: []sarifResult{}}
+	for _, path := range paths {
+		for _, e := range results[path] {
+			index, ok := ruleIndex[e.Reason]
+			if !ok {
+				index = len(driver.Rules)
+				ruleIndex[e.Reason] = index
+				driver.Rules = append(driver.Rules,
+					sarifRule{ID: e.Reason.String()})
+			}
+
+			region := sarifRegion{CharOffset: e.Pos.Start,
+				CharLength: e.Pos.End - e.Pos.Start}
+			if e.TextPos != nil {
+				region.StartLine = e.TextPos.Line
+				region.StartColumn = e.TextPos.Column
+			}
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    e.Reason.String(),
+				RuleIndex: index,
+				Level:     severityNames[e.Severity],
+				Message:   sarifMessage{Text: e.Error()},
+				Locations: []sarifLocation{{sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{
+						URI: filepath.ToSlash(path)},
+					Region: region,
+				}}},
+				PartialFingerprints: map[string]string{
+					"htmlcheck/v1": e.Fingerprint()},
+			})
+		}
+	}
+	run.Tool = sarifTool{Driver: driver}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
@@ -0,0 +1,59 @@
+package htmlcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func Test_WriteSARIF(t *testing.T) {
+	val := newValidator(ValidTag{Name: "b"})
+	str := "<b>\n<kk></kk></b>"
+	errors := val.ValidateHtmlString(str)
+	updateLineColumns(str, errors)
+
+	out := &bytes.Buffer{}
+	err := WriteSARIF(out, map[string][]*ValidationError{"pages/index.html": errors})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var log struct {
+		Version string
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct{ ID string }
+				}
+			}
+			Results []struct {
+				RuleID    string
+				Level     string
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct{ URI string }
+						Region           struct{ StartLine, StartColumn int }
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(out.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatal(out.String())
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 1 || run.Tool.Driver.Rules[0].ID != "InvalidTag" {
+		t.Fatal("expected one rule", run.Tool.Driver.Rules)
+	}
+	if len(run.Results) != 2 || run.Results[0].Level != "error" {
+		t.Fatal("expected two results", run.Results)
+	}
+	location := run.Results[0].Locations[0].PhysicalLocation
+	if location.ArtifactLocation.URI != "pages/index.html" ||
+		location.Region.StartLine != 2 || location.Region.StartColumn != 2 {
+		t.Fatal("unexpected location", location)
+	}
+}
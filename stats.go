@@ -0,0 +1,35 @@
+package htmlcheck
+
+import (
+	"io"
+
+	html "github.com/BlackEspresso/htmlcheck/htmlp"
+)
+
+// Stats counts how often each tag and attribute appears in a document,
+// whether valid or not. End tags are not counted.
+type Stats struct {
+	TagCounts  map[string]int
+	AttrCounts map[string]int
+}
+
+// ValidateHtmlWithStats validates r like ValidateHtml and also returns how
+// often each tag and attribute was used.
+func (v *Validator) ValidateHtmlWithStats(r io.Reader) ([]*ValidationError,
+	*Stats) {
+	errors := []*ValidationError{}
+	s := v.newValidation(r, func(err *ValidationError) bool {
+		errors = append(errors, err)
+		return true
+	})
+	s.stats = &Stats{TagCounts: map[string]int{}, AttrCounts: map[string]int{}}
+	v.run(s)
+	return errors, s.stats
+}
+
+func (s *validation) countToken(token html.Token) {
+	s.stats.TagCounts[token.Data]++
+	for _, attr := range token.Attr {
+		s.stats.AttrCounts[attr.Key]++
+	}
+}
@@ -0,0 +1,22 @@
+package htmlcheck
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_ValidateHtmlWithStats(t *testing.T) {
+	val := newValidator(ValidTag{Name: "p", Attrs: []string{"style"}},
+		ValidTag{Name: "br", IsSelfClosing: true})
+
+	errors, stats := val.ValidateHtmlWithStats(strings.NewReader(
+		`<p style="a">x<br/></p><p style="b" class="c"></p><font></font>`))
+	hasReason(t, errors, InvTag)
+	if stats.TagCounts["p"] != 2 || stats.TagCounts["br"] != 1 ||
+		stats.TagCounts["font"] != 1 || len(stats.TagCounts) != 3 {
+		t.Fatal(stats.TagCounts)
+	}
+	if stats.AttrCounts["style"] != 2 || stats.AttrCounts["class"] != 1 {
+		t.Fatal(stats.AttrCounts)
+	}
+}
@@ -0,0 +1,61 @@
+package htmlcheck
+
+// closest returns the candidate nearest to name, or "" if none is within
+// two edits. Ties go to the alphabetically first candidate.
+func closest(name string, candidates map[string]bool) string {
+	best, bestDistance := "", 3
+	for candidate := range candidates {
+		d := editDistance(name, candidate)
+		if d < bestDistance || d == bestDistance && candidate < best {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// suggestAttribute returns the attribute allowed on tagName, directly, as a
+// global attribute or as a declared data attribute, that is closest to
+// attrName. Attributes only allowed by a pattern have no name to suggest.
+func (v *Validator) suggestAttribute(tagName string, attrName string) string {
+	candidates := map[string]bool{}
+	for name := range v.validTagMap[tagName] {
+		candidates[name] = true
+	}
+	for name := range v.validTagMap[""] {
+		candidates[name] = true
+	}
+	for name := range v.globalAttrs {
+		candidates[name] = true
+	}
+	for name := range v.dataAttrs {
+		candidates[name] = true
+	}
+	return closest(attrName, candidates)
+}
@@ -0,0 +1,33 @@
+package htmlcheck
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ValidateSummaryString validates str and returns "" if it is valid or one
+// line per error reason, in order of first appearance, with the number of
+// errors and the first of them:
+//
+//	InvalidTag x2: tag 'kk' is not valid (1, 3) (L1, C2)
+func (v *Validator) ValidateSummaryString(str string) string {
+	errors := v.ValidateHtmlString(str)
+
+	order := []ErrorReason{}
+	first := map[ErrorReason]*ValidationError{}
+	counts := map[ErrorReason]int{}
+	for _, e := range errors {
+		if _, ok := first[e.Reason]; !ok {
+			first[e.Reason] = e
+			order = append(order, e.Reason)
+		}
+		counts[e.Reason]++
+	}
+
+	lines := make([]string, len(order))
+	for i, reason := range order {
+		lines[i] = reason.String() + " x" + strconv.Itoa(counts[reason]) + ": " +
+			first[reason].Error()
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,20 @@
+package htmlcheck
+
+import (
+	"testing"
+)
+
+func Test_ValidateSummaryString(t *testing.T) {
+	val := newValidator(ValidTag{Name: "a"})
+
+	if summary := val.ValidateSummaryString("<a></a>"); summary != "" {
+		t.Fatal("expected an empty summary", summary)
+	}
+
+	summary := val.ValidateSummaryString("<kk></kk>\n<a></b><kk>")
+	expected := "InvalidTag x4: tag 'kk' is not valid (1, 3) (L1, C2)\n" +
+		"NotProperlyClosed x1: tag 'a' is never closed (10, 13) (L2, C1)"
+	if summary != expected {
+		t.Fatal("unexpected summary", summary)
+	}
+}
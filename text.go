@@ -0,0 +1,37 @@
+package htmlcheck
+
+import (
+	"io"
+)
+
+// TextRun is a piece of text content and where it was found.
+type TextRun struct {
+	Text string
+	Pos  Span
+}
+
+// ValidateWithText validates r like ValidateHtml and also returns the
+// visible text of the document, leaving out script and style contents and
+// runs that are not meaningful content.
+func (v *Validator) ValidateWithText(r io.Reader) ([]*ValidationError,
+	[]TextRun) {
+	errors := []*ValidationError{}
+	s := v.newValidation(r, func(err *ValidationError) bool {
+		errors = append(errors, err)
+		return true
+	})
+	s.collectText = true
+	s.texts = []TextRun{}
+	v.run(s)
+	return errors, s.texts
+}
+
+func (v *Validator) collectText(s *validation, text string, pos Span) {
+	switch s.parent() {
+	case "script", "style", "template":
+		return
+	}
+	if v.IsMeaningfulContent(text) {
+		s.texts = append(s.texts, TextRun{Text: text, Pos: pos})
+	}
+}
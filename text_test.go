@@ -0,0 +1,24 @@
+package htmlcheck
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_ValidateWithText(t *testing.T) {
+	val := newValidator(ValidTag{Name: "p"}, ValidTag{Name: "script"},
+		ValidTag{Name: "style"})
+
+	html := "<style>p{}</style><p>Hello &amp; welcome</p>\n<script>x()</script><p>Bye</p>"
+	errors, texts := val.ValidateWithText(strings.NewReader(html))
+	checkErrors(t, errors)
+	if len(texts) != 2 {
+		t.Fatal("expected two text runs", texts)
+	}
+	if texts[0].Text != "Hello & welcome" || texts[1].Text != "Bye" {
+		t.Fatal("unexpected text", texts)
+	}
+	if html[texts[1].Pos.Start:texts[1].Pos.End] != "Bye" {
+		t.Fatal("unexpected position", texts[1].Pos)
+	}
+}
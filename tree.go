@@ -0,0 +1,113 @@
+package htmlcheck
+
+import (
+	html "github.com/BlackEspresso/htmlcheck/htmlp"
+)
+
+// Node is an element or a piece of text in the subtree passed to a close
+// rule. Text nodes have an empty TagName.
+type Node struct {
+	TagName  string
+	Attr     []html.Attribute
+	Text     string
+	Pos      Span
+	Children []*Node
+
+	textPos *TextPos
+}
+
+// CloseRule checks the subtree of an element once it is closed.
+type CloseRule func(node *Node) []*ValidationError
+
+// AddCloseRule registers fn to run for every tagName element when it is
+// closed, by its end tag, by the end tag of an ancestor or by the end of
+// the document. Void and self-closing elements are passed right away.
+// Errors without a position get the position of the element, errors with a
+// disabled reason are dropped.
+func (v *Validator) AddCloseRule(tagName string, fn CloseRule) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.closeRules == nil {
+		v.closeRules = map[string][]CloseRule{}
+	}
+	v.closeRules[tagName] = append(v.closeRules[tagName], fn)
+}
+
+// currentNode returns the node of the innermost open element that can have
+// children.
+func (s *validation) currentNode() *Node {
+	for i := len(s.parents) - 1; i >= 0; i-- {
+		if s.parents[i].node != nil {
+			return s.parents[i].node
+		}
+	}
+	return nil
+}
+
+// openNode adds a node for a start tag to the tree and returns it, or nil
+// if it is void and has been closed already.
+func (v *Validator) openNode(s *validation, token html.Token,
+	pos Span) *Node {
+	node := &Node{TagName: token.Data, Attr: token.Attr, Pos: pos,
+		textPos: s.textPos(pos.Start)}
+	if parent := s.currentNode(); parent != nil {
+		parent.Children = append(parent.Children, node)
+	}
+	if token.Type == html.SelfClosingTagToken ||
+		v.isValidSelfClosingTag(token.Data) {
+		v.runCloseRules(s, node)
+		return nil
+	}
+	return node
+}
+
+func (v *Validator) addTextNode(s *validation, text string, pos Span) {
+	if parent := s.currentNode(); parent != nil {
+		parent.Children = append(parent.Children,
+			&Node{Text: text, Pos: pos, textPos: s.textPos(pos.Start)})
+	}
+}
+
+// closeElements runs the close rules of elements taken off the parents
+// stack, innermost first.
+func (v *Validator) closeElements(s *validation, closed []element) {
+	for i := len(closed) - 1; i >= 0; i-- {
+		if closed[i].node != nil {
+			v.runCloseRules(s, closed[i].node)
+		}
+	}
+}
+
+func (v *Validator) runCloseRules(s *validation, node *Node) {
+	for _, rule := range v.closeRules[node.TagName] {
+		for _, cError := range rule(node) {
+			if v.stopped(s) {
+				return
+			}
+			if v.disabledReasons[cError.Reason] {
+				continue
+			}
+			if cError.Pos == (Span{}) {
+				cError.Pos = node.Pos
+			}
+			if cError.TextPos == nil {
+				cError.TextPos = node.textPosOf(cError.Pos.Start)
+			}
+			v.deliver(s, cError)
+		}
+	}
+}
+
+// textPosOf returns the line and column saved for the node of the subtree
+// that starts at offset, or nil if there is none.
+func (n *Node) textPosOf(offset int) *TextPos {
+	if n.Pos.Start == offset {
+		return n.textPos
+	}
+	for _, child := range n.Children {
+		if textPos := child.textPosOf(offset); textPos != nil {
+			return textPos
+		}
+	}
+	return nil
+}
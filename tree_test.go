@@ -0,0 +1,50 @@
+package htmlcheck
+
+import (
+	"testing"
+)
+
+func Test_CloseRule(t *testing.T) {
+	val := newValidator(ValidTag{Name: "ul"}, ValidTag{Name: "li"},
+		ValidTag{Name: "br", IsSelfClosing: true})
+
+	closed := []string{}
+	for _, name := range []string{"ul", "li", "br"} {
+		val.AddCloseRule(name, func(node *Node) []*ValidationError {
+			closed = append(closed, node.TagName)
+			return nil
+		})
+	}
+	val.AddCloseRule("ul", func(node *Node) []*ValidationError {
+		items := 0
+		for _, child := range node.Children {
+			if child.TagName == "li" {
+				items++
+			}
+		}
+		if items == 0 {
+			return []*ValidationError{{TagName: "ul", Reason: InvTag}}
+		}
+		return nil
+	})
+
+	errors := val.ValidateHtmlString("<ul><li>a<br>b</li><li>c</ul>")
+	hasReason(t, errors, InvNotProperlyClosed)
+	expected := []string{"br", "li", "li", "ul"}
+	if len(closed) != len(expected) {
+		t.Fatal("unexpected close order", closed)
+	}
+	for i := range expected {
+		if closed[i] != expected[i] {
+			t.Fatal("unexpected close order", closed)
+		}
+	}
+
+	errors = val.ValidateHtmlString("<ul> </ul>")
+	if len(errors) != 1 || errors[0].Pos.Start != 1 {
+		t.Fatal("expected the rule error at the ul", errors)
+	}
+	val.DisableReason(InvTag)
+	errors = val.ValidateHtmlString("<ul> </ul>")
+	checkErrors(t, errors)
+}
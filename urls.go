@@ -0,0 +1,50 @@
+package htmlcheck
+
+import (
+	"strings"
+)
+
+// DefaultURLAttrs are the attributes checked by CheckURLs unless URLAttrs
+// is set.
+var DefaultURLAttrs = []string{"href", "src", "action"}
+
+// DefaultAllowedSchemes are the URL schemes allowed by CheckURLs unless
+// AllowedSchemes is set. Relative URLs are always allowed.
+var DefaultAllowedSchemes = []string{"http", "https", "mailto", "tel"}
+
+// urlScheme returns the lower-cased scheme of a URL the way a browser reads
+// it: surrounding spaces and control characters are ignored and tabs and
+// line breaks are removed. It returns "" for relative URLs.
+func urlScheme(url string) string {
+	url = strings.TrimFunc(url, func(r rune) bool { return r <= ' ' })
+	url = strings.NewReplacer("\t", "", "\n", "", "\r", "").Replace(url)
+	colon := strings.IndexByte(url, ':')
+	if colon < 1 || !isLetter(url[0]) {
+		return ""
+	}
+	for _, c := range []byte(url[:colon]) {
+		if !isLetter(c) && !('0' <= c && c <= '9') && c != '+' && c != '-' &&
+			c != '.' {
+			return ""
+		}
+	}
+	return strings.ToLower(url[:colon])
+}
+
+// isUnsafeURL reports whether attr is checked by CheckURLs and its value
+// has a scheme that is not allowed.
+func (v *Validator) isUnsafeURL(attr string, value string) (string, bool) {
+	attrs := v.URLAttrs
+	if attrs == nil {
+		attrs = DefaultURLAttrs
+	}
+	if indexOf(attrs, attr) == -1 {
+		return "", false
+	}
+	scheme := urlScheme(value)
+	schemes := v.AllowedSchemes
+	if schemes == nil {
+		schemes = DefaultAllowedSchemes
+	}
+	return scheme, scheme != "" && indexOf(schemes, scheme) == -1
+}
@@ -0,0 +1,25 @@
+package htmlcheck
+
+import (
+	"testing"
+)
+
+func Test_CheckURLs(t *testing.T) {
+	val := newValidator(ValidTag{Name: "a", Attrs: []string{"href", "title"}},
+		ValidTag{Name: "img", Attrs: []string{"src"}, IsSelfClosing: true})
+	val.CheckURLs = true
+
+	errors := val.ValidateHtmlString(`<a href="/x?a=b:c"></a><a href="HTTPS://x"></a>` +
+		`<a href="mailto:a@b"></a><a title="javascript:x"></a><img src="img.png">`)
+	checkErrors(t, errors)
+
+	for _, url := range []string{"javascript:alert(1)", " JavaScript:x", "java\tscript:x",
+		"&#106;avascript:x", "data:text/html,x"} {
+		errors = val.ValidateHtmlString(`<a href="` + url + `"></a>`)
+		hasReason(t, errors, InvUnsafeURL)
+	}
+
+	val.AllowedSchemes = []string{"https", "data"}
+	errors = val.ValidateHtmlString(`<img src="data:image/png;base64,AAAA">`)
+	checkErrors(t, errors)
+}
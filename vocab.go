@@ -0,0 +1,139 @@
+package htmlcheck
+
+// LinkRelations is the set of known link types accepted by CheckRel, taken
+// from the HTML standard and the IANA link relation registry.
+var LinkRelations = map[string]bool{
+	"alternate":                    true,
+	"apple-touch-icon":             true,
+	"apple-touch-icon-precomposed": true,
+	"apple-touch-startup-image":    true,
+	"appendix":                     true,
+	"archives":                     true,
+	"author":                       true,
+	"bookmark":                     true,
+	"canonical":                    true,
+	"chapter":                      true,
+	"cite-as":                      true,
+	"contents":                     true,
+	"copyright":                    true,
+	"dns-prefetch":                 true,
+	"edit":                         true,
+	"enclosure":                    true,
+	"expect":                       true,
+	"external":                     true,
+	"first":                        true,
+	"glossary":                     true,
+	"help":                         true,
+	"hub":                          true,
+	"icon":                         true,
+	"index":                        true,
+	"last":                         true,
+	"license":                      true,
+	"manifest":                     true,
+	"mask-icon":                    true,
+	"me":                           true,
+	"modulepreload":                true,
+	"next":                         true,
+	"nofollow":                     true,
+	"noopener":                     true,
+	"noreferrer":                   true,
+	"opener":                       true,
+	"pingback":                     true,
+	"preconnect":                   true,
+	"prefetch":                     true,
+	"preload":                      true,
+	"prerender":                    true,
+	"prev":                         true,
+	"privacy-policy":               true,
+	"search":                       true,
+	"section":                      true,
+	"shortcut":                     true,
+	"shortlink":                    true,
+	"sponsored":                    true,
+	"start":                        true,
+	"stylesheet":                   true,
+	"subsection":                   true,
+	"tag":                          true,
+	"terms-of-service":             true,
+	"ugc":                          true,
+	"up":                           true,
+	"webmention":                   true,
+}
+
+// AutocompleteFields are the autofill field names accepted by
+// CheckAutocomplete that may not be preceded by a contact type.
+var AutocompleteFields = map[string]bool{
+	"name": true, "honorific-prefix": true, "given-name": true,
+	"additional-name": true, "family-name": true, "honorific-suffix": true,
+	"nickname": true, "username": true, "new-password": true,
+	"current-password": true, "one-time-code": true,
+	"organization-title": true, "organization": true, "street-address": true,
+	"address-line1": true, "address-line2": true, "address-line3": true,
+	"address-level4": true, "address-level3": true, "address-level2": true,
+	"address-level1": true, "country": true, "country-name": true,
+	"postal-code": true, "cc-name": true, "cc-given-name": true,
+	"cc-additional-name": true, "cc-family-name": true, "cc-number": true,
+	"cc-exp": true, "cc-exp-month": true, "cc-exp-year": true, "cc-csc": true,
+	"cc-type": true, "transaction-currency": true,
+	"transaction-amount": true, "language": true, "bday": true,
+	"bday-day": true, "bday-month": true, "bday-year": true, "sex": true,
+	"url": true, "photo": true,
+}
+
+// AutocompleteContactFields are the autofill field names that may be
+// preceded by one of AutocompleteContactTypes.
+var AutocompleteContactFields = map[string]bool{
+	"tel": true, "tel-country-code": true, "tel-national": true,
+	"tel-area-code": true, "tel-local": true, "tel-local-prefix": true,
+	"tel-local-suffix": true, "tel-extension": true, "email": true,
+	"impp": true,
+}
+
+// AutocompleteContactTypes are the autofill tokens that may precede one of
+// AutocompleteContactFields.
+var AutocompleteContactTypes = map[string]bool{
+	"home": true, "work": true, "mobile": true, "fax": true, "pager": true,
+}
+
+// HttpEquivValues are the pragma directives accepted by CheckHttpEquiv.
+var HttpEquivValues = map[string]bool{
+	"content-language":        true,
+	"content-type":            true,
+	"default-style":           true,
+	"refresh":                 true,
+	"x-ua-compatible":         true,
+	"content-security-policy": true,
+}
+
+// LabelableElements are the elements a <label> can be associated with.
+var LabelableElements = map[string]bool{
+	"button":   true,
+	"input":    true,
+	"meter":    true,
+	"output":   true,
+	"progress": true,
+	"select":   true,
+	"textarea": true,
+}
+
+// AriaAttributes are the ARIA states and properties accepted by
+// CheckAriaNames.
+var AriaAttributes = setOf("aria-activedescendant", "aria-atomic",
+	"aria-autocomplete", "aria-braillelabel", "aria-brailleroledescription",
+	"aria-busy", "aria-checked", "aria-colcount", "aria-colindex",
+	"aria-colindextext", "aria-colspan", "aria-controls", "aria-current",
+	"aria-describedby", "aria-description", "aria-details", "aria-disabled",
+	"aria-dropeffect", "aria-errormessage", "aria-expanded", "aria-flowto",
+	"aria-grabbed", "aria-haspopup", "aria-hidden", "aria-invalid",
+	"aria-keyshortcuts", "aria-label", "aria-labelledby", "aria-level",
+	"aria-live", "aria-modal", "aria-multiline", "aria-multiselectable",
+	"aria-orientation", "aria-owns", "aria-placeholder", "aria-posinset",
+	"aria-pressed", "aria-readonly", "aria-relevant", "aria-required",
+	"aria-roledescription", "aria-rowcount", "aria-rowindex",
+	"aria-rowindextext", "aria-rowspan", "aria-selected", "aria-setsize",
+	"aria-sort", "aria-valuemax", "aria-valuemin", "aria-valuenow",
+	"aria-valuetext")
+
+// LoadingValues are the values of the loading attribute accepted by
+// CheckLoading.
+var LoadingValues = setOf("lazy", "eager", "auto")